@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+const archetypeAliasesFile = "archetype-aliases.json"
+
+// ArchetypeAliases is the contents of data/archetype-aliases.json.
+// Aliases rename archetype labels in every tournament; Overrides pin the archetype
+// of individual players, keyed by tournament ID and then player name.
+type ArchetypeAliases struct {
+	Aliases   map[string]string            `json:"aliases"`
+	Overrides map[string]map[string]string `json:"overrides"`
+}
+
+// ArchetypeChange records one label rewrite so analysts can audit it
+type ArchetypeChange struct {
+	Player string `json:"player"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Source string `json:"source"`
+}
+
+// loadArchetypeAliases reads the alias and override mapping file
+func loadArchetypeAliases(path string) (*ArchetypeAliases, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read aliases %s: %w", path, err)
+	}
+
+	var aliases ArchetypeAliases
+	if err := json.Unmarshal(bytes, &aliases); err != nil {
+		return nil, fmt.Errorf("parse aliases %s: %w", path, err)
+	}
+
+	seen := make(map[string]string, len(aliases.Aliases))
+	for _, alias := range aliases.sortedAliases() {
+		key := aliasKey(alias)
+		if other, ok := seen[key]; ok {
+			return nil, fmt.Errorf("aliases %s: %q and %q differ only by case", path, other, alias)
+		}
+		seen[key] = alias
+	}
+
+	return &aliases, nil
+}
+
+// aliasKey is the case- and whitespace-insensitive form alias keys are matched by
+func aliasKey(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
+
+// sortedAliases returns the alias keys in sorted order
+func (a *ArchetypeAliases) sortedAliases() []string {
+	aliases := make([]string, 0, len(a.Aliases))
+	for alias := range a.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// resolve returns the canonical name for label. Alias keys match case-insensitively;
+// an exact key wins, and otherwise keys are tried in sorted order so the result
+// never depends on map iteration.
+func (a *ArchetypeAliases) resolve(label string) string {
+	if canonical, ok := a.Aliases[label]; ok {
+		return canonical
+	}
+	key := aliasKey(label)
+	for _, alias := range a.sortedAliases() {
+		if aliasKey(alias) == key {
+			return a.Aliases[alias]
+		}
+	}
+	return label
+}
+
+// apply rewrites playerArchetype in place: global aliases first, then the tournament's
+// per-player overrides. An override also labels a player missing from
+// playerArchetype, as long as players (normalized names of everyone in the
+// tournament's matches or decklists) has them; the change's From is then empty.
+// Every change is returned, sorted by player.
+func (a *ArchetypeAliases) apply(tournamentID string, playerArchetype map[string]string, players map[string]string) []ArchetypeChange {
+	changes := []ArchetypeChange{}
+
+	for player, archetype := range playerArchetype {
+		if canonical := a.resolve(archetype); canonical != archetype {
+			playerArchetype[player] = canonical
+			changes = append(changes, ArchetypeChange{Player: player, From: archetype, To: canonical, Source: "alias"})
+		}
+	}
+
+	for player, archetype := range a.Overrides[tournamentID] {
+		normalizedName := normalizePlayerName(player)
+		current, labelled := playerArchetype[normalizedName]
+		if _, played := players[normalizedName]; !labelled && !played {
+			log.Printf("  Warning: override for %q in tournament %s matches no player", player, tournamentID)
+			continue
+		}
+		if labelled && current == archetype {
+			continue
+		}
+		playerArchetype[normalizedName] = archetype
+		changes = append(changes, ArchetypeChange{Player: normalizedName, From: current, To: archetype, Source: "override"})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Player < changes[j].Player
+	})
+
+	return changes
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyArchetypeAliases(t *testing.T) {
+	aliases := &ArchetypeAliases{
+		Aliases: map[string]string{
+			"UR Prowess": "Izzet Prowess",
+		},
+		Overrides: map[string]map[string]string{
+			"100": {
+				"Carol  Smith": "Mono-Red",
+				"Nobody":       "Mono-Blue",
+				"Dave":         "Azorius Control",
+			},
+			"200": {
+				"alice": "Mono-Green",
+			},
+		},
+	}
+
+	playerArchetype := map[string]string{
+		"alice":       "ur prowess",
+		"bob":         "Izzet Prowess",
+		"carol smith": "Carol's Brew",
+	}
+
+	// Dave played but entered no deck name
+	players := map[string]string{"alice": "Alice", "bob": "Bob", "carol smith": "Carol Smith", "dave": "Dave"}
+	changes := aliases.apply("100", playerArchetype, players)
+
+	if playerArchetype["alice"] != "Izzet Prowess" {
+		t.Errorf("alias should match case-insensitively: %q", playerArchetype["alice"])
+	}
+	if playerArchetype["bob"] != "Izzet Prowess" {
+		t.Errorf("canonical label should be untouched: %q", playerArchetype["bob"])
+	}
+	if playerArchetype["carol smith"] != "Mono-Red" {
+		t.Errorf("override should apply by normalized name: %q", playerArchetype["carol smith"])
+	}
+	if playerArchetype["dave"] != "Azorius Control" {
+		t.Errorf("override should label an unlabeled player: %q", playerArchetype["dave"])
+	}
+	if _, ok := playerArchetype["nobody"]; ok {
+		t.Errorf("override for a player not in the tournament should be skipped")
+	}
+
+	want := []ArchetypeChange{
+		{Player: "alice", From: "ur prowess", To: "Izzet Prowess", Source: "alias"},
+		{Player: "carol smith", From: "Carol's Brew", To: "Mono-Red", Source: "override"},
+		{Player: "dave", From: "", To: "Azorius Control", Source: "override"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d: expected %+v, got %+v", i, want[i], changes[i])
+		}
+	}
+}
+
+func TestResolveArchetypeAlias_CaseVariants(t *testing.T) {
+	aliases := &ArchetypeAliases{Aliases: map[string]string{
+		"UR Prowess": "Izzet Prowess",
+		"ur prowess": "Izzet Tempo",
+	}}
+
+	if got := aliases.resolve("ur prowess"); got != "Izzet Tempo" {
+		t.Errorf("exact key should win: %q", got)
+	}
+	for i := 0; i < 20; i++ {
+		if got := aliases.resolve("Ur Prowess"); got != "Izzet Prowess" {
+			t.Fatalf("case-insensitive match should use sorted key order: %q", got)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), archetypeAliasesFile)
+	if err := os.WriteFile(path, []byte(`{"aliases": {"UR Prowess": "Izzet Prowess", "ur prowess ": "Izzet Tempo"}}`), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if _, err := loadArchetypeAliases(path); err == nil || !strings.Contains(err.Error(), "differ only by case") {
+		t.Errorf("expected colliding keys to be rejected, got %v", err)
+	}
+}
@@ -9,26 +9,45 @@ import (
 	"strings"
 )
 
-// Match represents a single match from the API
+// Match represents a single match from the API. Fields melee.gg returns that
+// aren't declared here are kept in Extra (see raw_fields.go) and written back
+// out, so they survive into the matches file.
 type Match struct {
-	TableNumber  int    `json:"TableNumber"`
-	ResultString string `json:"ResultString"`
-	Competitors  []struct {
-		Decklists []struct {
-			DecklistID   string `json:"DecklistId"`
-			PlayerID     int    `json:"PlayerId"`
-			DecklistName string `json:"DecklistName"`
-			Format       string `json:"Format"`
-			FormatID     string `json:"FormatId"`
-		} `json:"Decklists"`
-		Team struct {
-			Players []struct {
-				ID          int    `json:"ID"`
-				DisplayName string `json:"DisplayName"`
-				ScreenName  string `json:"ScreenName"`
-			} `json:"Players"`
-		} `json:"Team"`
-	} `json:"Competitors"`
+	TableNumber  int               `json:"TableNumber"`
+	ResultString string            `json:"ResultString"`
+	Competitors  []MatchCompetitor `json:"Competitors"`
+	Extra        rawFields         `json:"-"`
+}
+
+// MatchCompetitor is one side of a match
+type MatchCompetitor struct {
+	Decklists []MatchDecklist `json:"Decklists"`
+	Team      MatchTeam       `json:"Team"`
+	Extra     rawFields       `json:"-"`
+}
+
+// MatchDecklist is the decklist a competitor registered
+type MatchDecklist struct {
+	DecklistID   string    `json:"DecklistId"`
+	PlayerID     int       `json:"PlayerId"`
+	DecklistName string    `json:"DecklistName"`
+	Format       string    `json:"Format"`
+	FormatID     string    `json:"FormatId"`
+	Extra        rawFields `json:"-"`
+}
+
+// MatchTeam is a competitor's team, one player outside team events
+type MatchTeam struct {
+	Players []MatchPlayer `json:"Players"`
+	Extra   rawFields     `json:"-"`
+}
+
+// MatchPlayer is a player on a team
+type MatchPlayer struct {
+	ID          int       `json:"ID"`
+	DisplayName string    `json:"DisplayName"`
+	ScreenName  string    `json:"ScreenName"`
+	Extra       rawFields `json:"-"`
 }
 
 // MatchResponse is the API response structure
@@ -0,0 +1,171 @@
+package main
+
+import "sort"
+
+const (
+	aggregateMainSize      = 60
+	aggregateSideboardSize = 15
+)
+
+// CardInclusion describes how an archetype plays one card in one section of the deck
+type CardInclusion struct {
+	Name          string      `json:"name"`
+	Decks         int         `json:"decks"`
+	InclusionRate float64     `json:"inclusionRate"`
+	AvgCopies     float64     `json:"avgCopies"`
+	Core          bool        `json:"core"`
+	Copies        map[int]int `json:"copies"`
+}
+
+// ArchetypeDeckProfile is the card-level view of every decklist in one archetype
+type ArchetypeDeckProfile struct {
+	Archetype          string          `json:"archetype"`
+	Decks              int             `json:"decks"`
+	MainDeck           []CardInclusion `json:"mainDeck"`
+	Sideboard          []CardInclusion `json:"sideboard"`
+	AggregateMainDeck  []CardInfo      `json:"aggregateMainDeck"`
+	AggregateSideboard []CardInfo      `json:"aggregateSideboard"`
+}
+
+// TournamentArchetypeDecks holds the deck profile of every archetype in a tournament
+type TournamentArchetypeDecks struct {
+	Archetypes map[string]*ArchetypeDeckProfile `json:"archetypes"`
+}
+
+// aggregateArchetypeDecks computes per-archetype inclusion rates, copy-count
+// distributions and an aggregate 60/15 decklist. Decks without any cards are ignored.
+func aggregateArchetypeDecks(decklists []DeckInfo) *TournamentArchetypeDecks {
+	byArchetype := make(map[string][]DeckInfo)
+	for _, deck := range decklists {
+		if deck.Archetype == "" || (len(deck.MainDeck) == 0 && len(deck.Sideboard) == 0) {
+			continue
+		}
+		byArchetype[deck.Archetype] = append(byArchetype[deck.Archetype], deck)
+	}
+
+	result := &TournamentArchetypeDecks{
+		Archetypes: make(map[string]*ArchetypeDeckProfile),
+	}
+
+	for archetype, decks := range byArchetype {
+		mainCounts := make([]map[string]int, len(decks))
+		sideCounts := make([]map[string]int, len(decks))
+		for i, deck := range decks {
+			mainCounts[i] = cardCounts(deck.MainDeck)
+			sideCounts[i] = cardCounts(deck.Sideboard)
+		}
+
+		result.Archetypes[archetype] = &ArchetypeDeckProfile{
+			Archetype:          archetype,
+			Decks:              len(decks),
+			MainDeck:           cardInclusions(mainCounts),
+			Sideboard:          cardInclusions(sideCounts),
+			AggregateMainDeck:  aggregateDecklist(mainCounts, aggregateMainSize),
+			AggregateSideboard: aggregateDecklist(sideCounts, aggregateSideboardSize),
+		}
+	}
+
+	return result
+}
+
+// cardInclusions summarises one deck section across decks, most-played cards first
+func cardInclusions(decks []map[string]int) []CardInclusion {
+	byName := make(map[string]*CardInclusion)
+	totals := make(map[string]int)
+
+	for _, counts := range decks {
+		for name, quantity := range counts {
+			if quantity <= 0 {
+				continue
+			}
+			inc, exists := byName[name]
+			if !exists {
+				inc = &CardInclusion{Name: name, Copies: make(map[int]int)}
+				byName[name] = inc
+			}
+			inc.Decks++
+			inc.Copies[quantity]++
+			totals[name] += quantity
+		}
+	}
+
+	inclusions := make([]CardInclusion, 0, len(byName))
+	for name, inc := range byName {
+		inc.InclusionRate = float64(inc.Decks) / float64(len(decks)) * 100
+		inc.AvgCopies = float64(totals[name]) / float64(inc.Decks)
+		inc.Core = inc.Decks == len(decks)
+		inclusions = append(inclusions, *inc)
+	}
+
+	sort.Slice(inclusions, func(i, j int) bool {
+		if inclusions[i].Decks != inclusions[j].Decks {
+			return inclusions[i].Decks > inclusions[j].Decks
+		}
+		if inclusions[i].AvgCopies != inclusions[j].AvgCopies {
+			return inclusions[i].AvgCopies > inclusions[j].AvgCopies
+		}
+		return inclusions[i].Name < inclusions[j].Name
+	})
+
+	return inclusions
+}
+
+// aggregateDecklist builds a list of up to size cards from the most-played copies.
+// Every Nth copy of a card is a slot ranked by how many decks play at least N copies;
+// the top slots are kept. Because that count never grows with N, a card's 2nd copy
+// is never picked before its 1st.
+func aggregateDecklist(decks []map[string]int, size int) []CardInfo {
+	type slot struct {
+		name  string
+		copy  int
+		decks int
+	}
+
+	atLeast := make(map[string]map[int]int)
+	for _, counts := range decks {
+		for name, quantity := range counts {
+			if atLeast[name] == nil {
+				atLeast[name] = make(map[int]int)
+			}
+			for n := 1; n <= quantity; n++ {
+				atLeast[name][n]++
+			}
+		}
+	}
+
+	var slots []slot
+	for name, copies := range atLeast {
+		for n, count := range copies {
+			slots = append(slots, slot{name: name, copy: n, decks: count})
+		}
+	}
+
+	sort.Slice(slots, func(i, j int) bool {
+		if slots[i].decks != slots[j].decks {
+			return slots[i].decks > slots[j].decks
+		}
+		if slots[i].name != slots[j].name {
+			return slots[i].name < slots[j].name
+		}
+		return slots[i].copy < slots[j].copy
+	})
+
+	if len(slots) > size {
+		slots = slots[:size]
+	}
+
+	quantities := make(map[string]int)
+	var order []string
+	for _, s := range slots {
+		if quantities[s.name] == 0 {
+			order = append(order, s.name)
+		}
+		quantities[s.name]++
+	}
+
+	list := make([]CardInfo, 0, len(order))
+	for _, name := range order {
+		list = append(list, CardInfo{Quantity: quantities[name], Name: name})
+	}
+	return list
+}
@@ -0,0 +1,76 @@
+package main
+
+import "testing"
+
+func TestAggregateArchetypeDecks(t *testing.T) {
+	decklists := []DeckInfo{
+		{
+			PlayerName: "Alice",
+			Archetype:  "Izzet Prowess",
+			MainDeck:   []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 2, Name: "Shock"}},
+			Sideboard:  []CardInfo{{Quantity: 2, Name: "Negate"}},
+		},
+		{
+			PlayerName: "Bob",
+			Archetype:  "Izzet Prowess",
+			MainDeck:   []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 1, Name: "Spell Pierce"}},
+		},
+		{
+			PlayerName: "Carol",
+			Archetype:  "Izzet Prowess",
+			MainDeck:   []CardInfo{{Quantity: 3, Name: "Opt"}, {Quantity: 1, Name: "Shock"}},
+		},
+		{
+			PlayerName: "Dave",
+			Archetype:  "Izzet Prowess",
+		},
+	}
+
+	profiles := aggregateArchetypeDecks(decklists)
+	izzet := profiles.Archetypes["Izzet Prowess"]
+	if izzet == nil {
+		t.Fatal("missing Izzet Prowess profile")
+	}
+	if izzet.Decks != 3 {
+		t.Errorf("expected 3 decks (empty list ignored), got %d", izzet.Decks)
+	}
+
+	opt := izzet.MainDeck[0]
+	if opt.Name != "Opt" || !opt.Core || opt.InclusionRate != 100 {
+		t.Errorf("Opt should be the first, core card: %+v", opt)
+	}
+	if opt.Copies[4] != 2 || opt.Copies[3] != 1 {
+		t.Errorf("Opt copy distribution wrong: %v", opt.Copies)
+	}
+
+	shock := izzet.MainDeck[1]
+	if shock.Name != "Shock" || shock.Core || shock.Decks != 2 || shock.AvgCopies != 1.5 {
+		t.Errorf("Shock should be a flex slot in 2 decks: %+v", shock)
+	}
+
+	if len(izzet.Sideboard) != 1 || izzet.Sideboard[0].Name != "Negate" {
+		t.Errorf("sideboard inclusions wrong: %+v", izzet.Sideboard)
+	}
+}
+
+func TestAggregateDecklist(t *testing.T) {
+	decks := []map[string]int{
+		{"Opt": 4, "Shock": 2},
+		{"Opt": 4, "Spell Pierce": 1},
+		{"Opt": 3, "Shock": 1},
+	}
+
+	// Slots by decks playing at least N copies: Opt 1-3 (3), Opt 4 and Shock 1 (2),
+	// then Shock 2 and Spell Pierce 1 (1) tie and fall back to name order.
+	list := aggregateDecklist(decks, 6)
+
+	want := []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 2, Name: "Shock"}}
+	if len(list) != len(want) {
+		t.Fatalf("expected %v, got %v", want, list)
+	}
+	for i := range want {
+		if list[i] != want[i] {
+			t.Errorf("slot %d: expected %+v, got %+v", i, want[i], list[i])
+		}
+	}
+}
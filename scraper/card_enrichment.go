@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/sp3c1/protour-data-viz/scraper/carddata"
+)
+
+// decklistCardNames returns every distinct card name across the decklists
+func decklistCardNames(decklists []DeckInfo) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, deck := range decklists {
+		for _, section := range [][]CardInfo{deck.MainDeck, deck.Sideboard} {
+			for _, card := range section {
+				if !seen[card.Name] {
+					seen[card.Name] = true
+					names = append(names, card.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// enrichDecklists fills in oracle metadata for every card and a DeckSummary for
+// every non-empty deck, in place. Scraped names are kept as-is. It fails with a
+// *carddata.UnresolvedError if any name is unknown to db.
+func enrichDecklists(decklists []DeckInfo, db *carddata.DB) error {
+	resolved, err := db.ResolveAll(decklistCardNames(decklists))
+	if err != nil {
+		return err
+	}
+
+	for i := range decklists {
+		for _, section := range [][]CardInfo{decklists[i].MainDeck, decklists[i].Sideboard} {
+			for j := range section {
+				enrichCard(&section[j], resolved[section[j].Name])
+			}
+		}
+		if len(decklists[i].MainDeck) > 0 {
+			decklists[i].Summary = summarizeDeck(decklists[i], resolved)
+		}
+	}
+	return nil
+}
+
+func enrichCard(card *CardInfo, data *carddata.Card) {
+	manaValue := data.ManaValue
+	card.OracleID = data.OracleID
+	card.ManaValue = &manaValue
+	card.Colors = strings.Join(data.Colors, "")
+	card.TypeLine = data.TypeLine
+	card.Rarity = data.Rarity
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sp3c1/protour-data-viz/scraper/carddata"
+)
+
+// CardImageEntry is one card's images in the image manifest. Back is set for
+// double-faced cards only.
+type CardImageEntry struct {
+	OracleID string           `json:"oracleId"`
+	Normal   string           `json:"normal"`
+	ArtCrop  string           `json:"artCrop"`
+	Back     *carddata.Images `json:"back,omitempty"`
+}
+
+// CardImageManifest maps every decklist card name to its images. Unmatched names
+// have no card in the bulk file; NoImages names resolved to a card without images.
+type CardImageManifest struct {
+	GeneratedAt time.Time                 `json:"generatedAt"`
+	Cards       map[string]CardImageEntry `json:"cards"`
+	Unmatched   []string                  `json:"unmatched"`
+	NoImages    []string                  `json:"noImages"`
+}
+
+// runCardImages implements the card-images command
+func runCardImages(args []string) error {
+	fs := flag.NewFlagSet("card-images", flag.ExitOnError)
+	cardDataFlag := fs.String("carddata", "", "Local Scryfall default-cards bulk file (required)")
+	outputFlag := fs.String("output", "../web/public/card-images.json", "Name to normal image URL map used by the web app")
+	manifestFlag := fs.String("manifest", "../web/public/card-image-manifest.json", "Full image manifest (normal, art crop and back faces)")
+	strictFlag := fs.Bool("strict", false, "Fail when any card name has no image")
+	fs.Parse(args)
+
+	if *cardDataFlag == "" {
+		return fmt.Errorf("-carddata is required")
+	}
+
+	registryPath := filepath.Join(outputDir, registryFile)
+	registry, err := loadRegistry(registryPath)
+	if err != nil {
+		return err
+	}
+	names, err := collectCardNames(registry)
+	if err != nil {
+		return err
+	}
+
+	db, err := carddata.Load(*cardDataFlag)
+	if err != nil {
+		return err
+	}
+	log.Printf("Found %d unique cards across %d tournaments, %d cards in %s", len(names), len(registry), db.Len(), *cardDataFlag)
+
+	manifest := buildImageManifest(names, db)
+	for _, name := range manifest.Unmatched {
+		log.Printf("  ⚠ No card data match: %s", name)
+	}
+	for _, name := range manifest.NoImages {
+		log.Printf("  ⚠ No image in card data: %s", name)
+	}
+
+	if err := writeJSON(*outputFlag, manifest.imageCache()); err != nil {
+		return err
+	}
+	if err := writeJSON(*manifestFlag, manifest); err != nil {
+		return err
+	}
+
+	missing := len(manifest.Unmatched) + len(manifest.NoImages)
+	log.Printf("Cached images for %d cards, %d missing", len(manifest.Cards), missing)
+	if *strictFlag && missing > 0 {
+		return fmt.Errorf("%d cards have no image", missing)
+	}
+	return nil
+}
+
+// collectCardNames returns the sorted, distinct card names in every registry
+// tournament's decklists. Tournaments without a decklists file are skipped.
+func collectCardNames(registry Registry) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, t := range registry {
+		decklists, err := loadDecklists(t.ID)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			log.Printf("  Skipping tournament %s — no decklists file", t.ID)
+			continue
+		case err != nil:
+			return nil, err
+		}
+		for _, deck := range decklists {
+			for _, section := range [][]CardInfo{deck.MainDeck, deck.Sideboard} {
+				for _, card := range section {
+					seen[card.Name] = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// buildImageManifest resolves each name and records its front and back images
+func buildImageManifest(names []string, db *carddata.DB) *CardImageManifest {
+	manifest := &CardImageManifest{
+		GeneratedAt: time.Now().UTC(),
+		Cards:       make(map[string]CardImageEntry),
+		Unmatched:   []string{},
+		NoImages:    []string{},
+	}
+
+	for _, name := range names {
+		card, ok := db.Resolve(name)
+		if !ok {
+			manifest.Unmatched = append(manifest.Unmatched, name)
+			continue
+		}
+		if len(card.Images) == 0 {
+			manifest.NoImages = append(manifest.NoImages, name)
+			continue
+		}
+
+		entry := CardImageEntry{
+			OracleID: card.OracleID,
+			Normal:   card.Images[0].Normal,
+			ArtCrop:  card.Images[0].ArtCrop,
+		}
+		if len(card.Images) > 1 {
+			back := card.Images[1]
+			entry.Back = &back
+		}
+		manifest.Cards[name] = entry
+	}
+
+	return manifest
+}
+
+// imageCache flattens the manifest to the name -> normal URL map the web app
+// reads, with null for cards that have no image
+func (m *CardImageManifest) imageCache() map[string]*string {
+	cache := make(map[string]*string, len(m.Cards)+len(m.Unmatched)+len(m.NoImages))
+	for name, entry := range m.Cards {
+		url := entry.Normal
+		cache[name] = &url
+	}
+	for _, name := range append(append([]string{}, m.Unmatched...), m.NoImages...) {
+		cache[name] = nil
+	}
+	return cache
+}
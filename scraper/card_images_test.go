@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sp3c1/protour-data-viz/scraper/carddata"
+)
+
+func TestBuildImageManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cards.json")
+	bulk := `[
+	{"oracle_id": "o-opt", "name": "Opt", "layout": "normal", "image_uris": {"normal": "opt-normal", "art_crop": "opt-art"}},
+	{"oracle_id": "o-delver", "name": "Delver of Secrets // Insectile Aberration", "layout": "transform",
+	 "card_faces": [
+		{"name": "Delver of Secrets", "image_uris": {"normal": "delver-normal", "art_crop": "delver-art"}},
+		{"name": "Insectile Aberration", "image_uris": {"normal": "insect-normal", "art_crop": "insect-art"}}
+	 ]},
+	{"oracle_id": "o-blank", "name": "Blank Card", "layout": "normal", "image_status": "missing"}
+]`
+	if err := os.WriteFile(path, []byte(bulk), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	db, err := carddata.Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	manifest := buildImageManifest([]string{"Blank Card", "Brand New Card", "Delver of Secrets // Insectile Aberration", "Opt"}, db)
+
+	wantCards := map[string]CardImageEntry{
+		"Opt": {OracleID: "o-opt", Normal: "opt-normal", ArtCrop: "opt-art"},
+		"Delver of Secrets // Insectile Aberration": {
+			OracleID: "o-delver",
+			Normal:   "delver-normal",
+			ArtCrop:  "delver-art",
+			Back:     &carddata.Images{Normal: "insect-normal", ArtCrop: "insect-art"},
+		},
+	}
+	if !reflect.DeepEqual(manifest.Cards, wantCards) {
+		t.Errorf("cards: expected %+v, got %+v", wantCards, manifest.Cards)
+	}
+	if !reflect.DeepEqual(manifest.Unmatched, []string{"Brand New Card"}) {
+		t.Errorf("unmatched: %v", manifest.Unmatched)
+	}
+	if !reflect.DeepEqual(manifest.NoImages, []string{"Blank Card"}) {
+		t.Errorf("noImages: %v", manifest.NoImages)
+	}
+
+	cache := manifest.imageCache()
+	if len(cache) != 4 || cache["Brand New Card"] != nil || cache["Blank Card"] != nil {
+		t.Errorf("missing cards should map to null: %v", cache)
+	}
+	if url := cache["Delver of Secrets // Insectile Aberration"]; url == nil || *url != "delver-normal" {
+		t.Errorf("web cache should use the front face: %v", url)
+	}
+}
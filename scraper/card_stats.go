@@ -0,0 +1,188 @@
+package main
+
+// PlayerRecord is a single player's match record across all scraped rounds
+type PlayerRecord struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+}
+
+// CardArchetypeStats is the performance of decks playing a card within one archetype
+type CardArchetypeStats struct {
+	Decks   int     `json:"decks"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	Draws   int     `json:"draws"`
+	WinRate float64 `json:"winRate"`
+}
+
+// CardStats describes how often a card is played and how the decks playing it performed
+type CardStats struct {
+	Name               string                         `json:"name"`
+	Decks              int                            `json:"decks"`
+	MainDecks          int                            `json:"mainDecks"`
+	SideboardDecks     int                            `json:"sideboardDecks"`
+	AvgMainCopies      float64                        `json:"avgMainCopies"`
+	AvgSideboardCopies float64                        `json:"avgSideboardCopies"`
+	Wins               int                            `json:"wins"`
+	Losses             int                            `json:"losses"`
+	Draws              int                            `json:"draws"`
+	WinRate            float64                        `json:"winRate"`
+	Archetypes         map[string]*CardArchetypeStats `json:"archetypes"`
+}
+
+// TournamentCardStats represents per-card statistics for a tournament
+type TournamentCardStats struct {
+	TotalDecks int                   `json:"totalDecks"`
+	Cards      map[string]*CardStats `json:"cards"`
+}
+
+// buildPlayerRecords computes each player's match record, keyed by normalized player name.
+// Byes and matches with a missing player are skipped, matching aggregateStats.
+func buildPlayerRecords(allMatches map[int][]Match) map[string]*PlayerRecord {
+	records := make(map[string]*PlayerRecord)
+
+	record := func(name string) *PlayerRecord {
+		if _, exists := records[name]; !exists {
+			records[name] = &PlayerRecord{}
+		}
+		return records[name]
+	}
+
+	for _, matches := range allMatches {
+		for _, match := range matches {
+			if len(match.Competitors) < 2 {
+				continue
+			}
+			if len(match.Competitors[0].Team.Players) == 0 || len(match.Competitors[1].Team.Players) == 0 {
+				continue
+			}
+
+			player1Name := match.Competitors[0].Team.Players[0].DisplayName
+			player2Name := match.Competitors[1].Team.Players[0].DisplayName
+			if player1Name == "" || player2Name == "" {
+				continue
+			}
+
+			p1 := record(normalizePlayerName(player1Name))
+			p2 := record(normalizePlayerName(player2Name))
+
+			winner, _, _, _ := parseMatchResult(match.ResultString)
+			switch {
+			case winner == "":
+				p1.Draws++
+				p2.Draws++
+			case normalizePlayerName(winner) == normalizePlayerName(player1Name):
+				p1.Wins++
+				p2.Losses++
+			default:
+				p2.Wins++
+				p1.Losses++
+			}
+		}
+	}
+
+	return records
+}
+
+// cardCounts sums quantities per card name, merging duplicate entries
+func cardCounts(cards []CardInfo) map[string]int {
+	counts := make(map[string]int)
+	for _, card := range cards {
+		counts[card.Name] += card.Quantity
+	}
+	return counts
+}
+
+// aggregateCardStats joins decklists with match results at the card level.
+// A deck counts towards a card if the card appears in its main deck or sideboard;
+// average copies are taken over all decks playing the card.
+func aggregateCardStats(allMatches map[int][]Match, decklists []DeckInfo) *TournamentCardStats {
+	records := buildPlayerRecords(allMatches)
+	stats := &TournamentCardStats{
+		Cards: make(map[string]*CardStats),
+	}
+
+	mainTotals := make(map[string]int)
+	sideTotals := make(map[string]int)
+
+	for _, deck := range decklists {
+		if len(deck.MainDeck) == 0 && len(deck.Sideboard) == 0 {
+			continue
+		}
+		stats.TotalDecks++
+
+		record := records[normalizePlayerName(deck.PlayerName)]
+		if record == nil {
+			record = &PlayerRecord{}
+		}
+
+		main := cardCounts(deck.MainDeck)
+		side := cardCounts(deck.Sideboard)
+
+		played := make(map[string]bool)
+		for name := range main {
+			played[name] = true
+		}
+		for name := range side {
+			played[name] = true
+		}
+
+		for name := range played {
+			card, exists := stats.Cards[name]
+			if !exists {
+				card = &CardStats{
+					Name:       name,
+					Archetypes: make(map[string]*CardArchetypeStats),
+				}
+				stats.Cards[name] = card
+			}
+
+			card.Decks++
+			if main[name] > 0 {
+				card.MainDecks++
+				mainTotals[name] += main[name]
+			}
+			if side[name] > 0 {
+				card.SideboardDecks++
+				sideTotals[name] += side[name]
+			}
+			card.Wins += record.Wins
+			card.Losses += record.Losses
+			card.Draws += record.Draws
+
+			if deck.Archetype == "" {
+				continue
+			}
+			arch, exists := card.Archetypes[deck.Archetype]
+			if !exists {
+				arch = &CardArchetypeStats{}
+				card.Archetypes[deck.Archetype] = arch
+			}
+			arch.Decks++
+			arch.Wins += record.Wins
+			arch.Losses += record.Losses
+			arch.Draws += record.Draws
+		}
+	}
+
+	for name, card := range stats.Cards {
+		card.AvgMainCopies = float64(mainTotals[name]) / float64(card.Decks)
+		card.AvgSideboardCopies = float64(sideTotals[name]) / float64(card.Decks)
+		card.WinRate = winRate(card.Wins, card.Losses)
+		for _, arch := range card.Archetypes {
+			arch.WinRate = winRate(arch.Wins, arch.Losses)
+		}
+	}
+
+	return stats
+}
+
+// winRate returns wins as a percentage of decided matches (draws excluded)
+func winRate(wins, losses int) float64 {
+	total := wins + losses
+	if total == 0 {
+		return 0
+	}
+	return float64(wins) / float64(total) * 100
+}
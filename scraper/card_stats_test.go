@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// testMatch builds a two-player Match the way melee.gg returns it
+func testMatch(t *testing.T, player1, player2, result string) Match {
+	t.Helper()
+	raw := fmt.Sprintf(`{
+		"TableNumber": 1,
+		"ResultString": %q,
+		"Competitors": [
+			{"Team": {"Players": [{"DisplayName": %q}]}},
+			{"Team": {"Players": [{"DisplayName": %q}]}}
+		]
+	}`, result, player1, player2)
+
+	var m Match
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return m
+}
+
+func TestBuildPlayerRecords(t *testing.T) {
+	matches := map[int][]Match{
+		4: {
+			testMatch(t, "Alice", "Bob", "Alice won 2-1-0"),
+			testMatch(t, "Carol", "Dave", "1-1-0 Draw"),
+		},
+		5: {
+			testMatch(t, "Alice", "Carol", "Carol won 2-0-0"),
+		},
+	}
+
+	records := buildPlayerRecords(matches)
+
+	if r := records["alice"]; r == nil || r.Wins != 1 || r.Losses != 1 || r.Draws != 0 {
+		t.Errorf("alice record wrong: %+v", r)
+	}
+	if r := records["carol"]; r == nil || r.Wins != 1 || r.Draws != 1 {
+		t.Errorf("carol record wrong: %+v", r)
+	}
+	if r := records["bob"]; r == nil || r.Losses != 1 {
+		t.Errorf("bob record wrong: %+v", r)
+	}
+}
+
+func TestAggregateCardStats(t *testing.T) {
+	matches := map[int][]Match{
+		4: {
+			testMatch(t, "Alice", "Bob", "Alice won 2-0-0"),
+			testMatch(t, "Carol", "Dave", "Dave won 2-1-0"),
+		},
+	}
+	decklists := []DeckInfo{
+		{
+			PlayerName: "Alice",
+			Archetype:  "Izzet Prowess",
+			MainDeck:   []CardInfo{{Quantity: 4, Name: "Lightning Bolt"}, {Quantity: 2, Name: "Opt"}, {Quantity: 1, Name: "Opt"}},
+			Sideboard:  []CardInfo{{Quantity: 2, Name: "Negate"}},
+		},
+		{
+			PlayerName: "Bob",
+			Archetype:  "Mono-Red",
+			MainDeck:   []CardInfo{{Quantity: 4, Name: "Lightning Bolt"}},
+		},
+		{
+			PlayerName: "Carol",
+			Archetype:  "Izzet Prowess",
+			MainDeck:   []CardInfo{{Quantity: 2, Name: "Lightning Bolt"}},
+			Sideboard:  []CardInfo{{Quantity: 1, Name: "Lightning Bolt"}},
+		},
+		{
+			PlayerName: "Dave",
+			Archetype:  "Placeholder",
+		},
+	}
+
+	stats := aggregateCardStats(matches, decklists)
+
+	if stats.TotalDecks != 3 {
+		t.Errorf("expected 3 decks with cards, got %d", stats.TotalDecks)
+	}
+
+	bolt := stats.Cards["Lightning Bolt"]
+	if bolt == nil {
+		t.Fatal("missing Lightning Bolt")
+	}
+	if bolt.Decks != 3 || bolt.MainDecks != 3 || bolt.SideboardDecks != 1 {
+		t.Errorf("bolt deck counts wrong: %+v", bolt)
+	}
+	if math.Abs(bolt.AvgMainCopies-10.0/3) > 1e-9 || math.Abs(bolt.AvgSideboardCopies-1.0/3) > 1e-9 {
+		t.Errorf("bolt averages wrong: main=%v side=%v", bolt.AvgMainCopies, bolt.AvgSideboardCopies)
+	}
+	if bolt.Wins != 1 || bolt.Losses != 2 {
+		t.Errorf("bolt record wrong: %d-%d", bolt.Wins, bolt.Losses)
+	}
+
+	izzet := bolt.Archetypes["Izzet Prowess"]
+	if izzet == nil || izzet.Decks != 2 || izzet.Wins != 1 || izzet.Losses != 1 || izzet.WinRate != 50 {
+		t.Errorf("bolt Izzet Prowess breakdown wrong: %+v", izzet)
+	}
+
+	opt := stats.Cards["Opt"]
+	if opt == nil || opt.AvgMainCopies != 3 || opt.WinRate != 100 {
+		t.Errorf("duplicate Opt entries should be merged: %+v", opt)
+	}
+}
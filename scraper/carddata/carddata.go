@@ -0,0 +1,216 @@
+// Package carddata resolves scraped card names against a local bulk card file
+// (Scryfall default-cards or MTGJSON AtomicCards) and exposes oracle-level metadata.
+package carddata
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Card is the oracle-level metadata for one card
+type Card struct {
+	OracleID      string            `json:"oracleId"`
+	Name          string            `json:"name"`
+	ManaValue     float64           `json:"manaValue"`
+	Colors        []string          `json:"colors"`
+	ColorIdentity []string          `json:"colorIdentity"`
+	TypeLine      string            `json:"typeLine"`
+	Rarity        string            `json:"rarity,omitempty"`
+	Layout        string            `json:"layout"`
+	Faces         []string          `json:"faces,omitempty"`
+	Legalities    map[string]string `json:"legalities,omitempty"`
+	// Images holds one entry per face with its own art: a single entry for normal,
+	// split and adventure cards, front then back for double-faced cards. Only
+	// Scryfall bulk files carry image URIs.
+	Images []Images `json:"images,omitempty"`
+}
+
+// Images are the Scryfall image URIs for one card face
+type Images struct {
+	Normal  string `json:"normal"`
+	ArtCrop string `json:"artCrop"`
+}
+
+// DB is an in-memory card index keyed by normalized name
+type DB struct {
+	cards  []*Card
+	byName map[string]*Card
+	// faceOnly marks keys that came from a non-front face; a full or front-face
+	// name always wins over them.
+	faceOnly map[string]bool
+}
+
+// UnresolvedError lists the names a DB could not resolve
+type UnresolvedError struct {
+	Names []string
+}
+
+func (e *UnresolvedError) Error() string {
+	return fmt.Sprintf("%d unresolved card names: %s", len(e.Names), strings.Join(e.Names, ", "))
+}
+
+// Load reads a Scryfall default-cards (JSON array) or MTGJSON AtomicCards (JSON object)
+// bulk file. The format is detected from the first JSON token.
+func Load(path string) (*DB, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open card data %s: %w", path, err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReaderSize(file, 1<<20))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("read card data %s: %w", path, err)
+	}
+
+	db := newDB()
+	switch token {
+	case json.Delim('['):
+		err = db.loadScryfall(decoder)
+	case json.Delim('{'):
+		err = db.loadMTGJSON(decoder)
+	default:
+		err = fmt.Errorf("unrecognized bulk format")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load card data %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+func newDB() *DB {
+	return &DB{
+		byName:   make(map[string]*Card),
+		faceOnly: make(map[string]bool),
+	}
+}
+
+// Len returns the number of distinct oracle cards loaded
+func (db *DB) Len() int {
+	return len(db.cards)
+}
+
+// add indexes card under its full name and each face name
+func (db *DB) add(card *Card) {
+	db.cards = append(db.cards, card)
+	db.index(card.Name, card, false)
+	for i, face := range card.Faces {
+		db.index(face, card, i > 0)
+	}
+}
+
+func (db *DB) index(name string, card *Card, faceOnly bool) {
+	key := NormalizeName(name)
+	if key == "" {
+		return
+	}
+	if _, exists := db.byName[key]; exists && (faceOnly || !db.faceOnly[key]) {
+		return
+	}
+	db.byName[key] = card
+	db.faceOnly[key] = faceOnly
+}
+
+// Resolve finds the oracle card for a scraped name. Split, adventure and
+// double-faced cards resolve by full name ("Fire // Ice", "Fire/Ice") or by any
+// face name; accents and case are ignored.
+func (db *DB) Resolve(name string) (*Card, bool) {
+	card, ok := db.byName[NormalizeName(name)]
+	return card, ok
+}
+
+// ResolveAll resolves every name, returning an *UnresolvedError listing the
+// names that could not be resolved
+func (db *DB) ResolveAll(names []string) (map[string]*Card, error) {
+	resolved := make(map[string]*Card, len(names))
+	var missing []string
+	for _, name := range names {
+		if _, done := resolved[name]; done {
+			continue
+		}
+		card, ok := db.Resolve(name)
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		resolved[name] = card
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return resolved, &UnresolvedError{Names: missing}
+	}
+	return resolved, nil
+}
+
+var (
+	faceSeparator = regexp.MustCompile(`\s*/{1,2}\s*`)
+	whitespace    = regexp.MustCompile(`\s+`)
+	accentFolder  = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+)
+
+// NormalizeName folds case, accents, whitespace and face separators so that
+// "Lim-Dûl's Vault", "lim-dul's vault" and "Fire/Ice" / "Fire // Ice" compare equal
+func NormalizeName(name string) string {
+	folded, _, err := transform.String(accentFolder, name)
+	if err != nil {
+		folded = name
+	}
+	folded = strings.ReplaceAll(folded, "’", "'")
+	folded = faceSeparator.ReplaceAllString(folded, " // ")
+	folded = whitespace.ReplaceAllString(folded, " ")
+	return strings.ToLower(strings.TrimSpace(folded))
+}
+
+// Legality returns the card's status in format ("legal", "not_legal", "banned" or
+// "restricted"). A format missing from a card's legalities counts as not legal;
+// ok is false only when the bulk file carried no legalities for the card at all.
+func (c *Card) Legality(format string) (status string, ok bool) {
+	if len(c.Legalities) == 0 {
+		return "", false
+	}
+	if status, found := c.Legalities[strings.ToLower(format)]; found {
+		return status, true
+	}
+	return "not_legal", true
+}
+
+// normalizeLegalities lowercases format names and statuses. MTGJSON omits formats
+// a card is not legal in; Scryfall lists them as "not_legal".
+func normalizeLegalities(in map[string]string) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for format, status := range in {
+		out[strings.ToLower(format)] = strings.ToLower(status)
+	}
+	return out
+}
+
+// sortedColors returns the distinct colors in WUBRG order
+func sortedColors(colors []string) []string {
+	seen := make(map[string]bool)
+	for _, c := range colors {
+		seen[strings.ToUpper(c)] = true
+	}
+	out := []string{}
+	for _, c := range []string{"W", "U", "B", "R", "G"} {
+		if seen[c] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,173 @@
+package carddata
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const scryfallFixture = `[
+	{"oracle_id": "o-bolt", "name": "Lightning Bolt", "layout": "normal", "cmc": 1, "colors": ["R"], "color_identity": ["R"], "type_line": "Instant", "rarity": "common", "released_at": "2010-07-16"},
+	{"oracle_id": "o-bolt", "name": "Lightning Bolt", "layout": "normal", "cmc": 1, "colors": ["R"], "color_identity": ["R"], "type_line": "Instant", "rarity": "uncommon", "released_at": "2024-01-01"},
+	{"oracle_id": "o-fire", "name": "Fire // Ice", "layout": "split", "cmc": 4, "colors": ["U", "R"], "color_identity": ["U", "R"], "type_line": "Instant // Instant", "rarity": "uncommon", "released_at": "2001-01-01",
+	 "card_faces": [{"name": "Fire", "colors": ["R"]}, {"name": "Ice", "colors": ["U"]}]},
+	{"oracle_id": "o-delver", "name": "Delver of Secrets // Insectile Aberration", "layout": "transform", "cmc": 1, "color_identity": ["U"], "type_line": "Creature — Human Wizard // Creature — Human Insect", "rarity": "common", "released_at": "2011-09-30",
+	 "card_faces": [{"name": "Delver of Secrets", "colors": ["U"]}, {"name": "Insectile Aberration", "colors": ["U"]}]},
+	{"oracle_id": "o-vault", "name": "Lim-Dûl's Vault", "layout": "normal", "cmc": 2, "colors": ["B", "U"], "color_identity": ["U", "B"], "type_line": "Instant", "rarity": "uncommon", "released_at": "1996-06-10"},
+	{"oracle_id": "o-token", "name": "Goblin", "layout": "token", "cmc": 0, "type_line": "Token Creature — Goblin", "rarity": "common"}
+]`
+
+const mtgjsonFixture = `{
+	"meta": {"version": "5.2.2"},
+	"data": {
+		"Bonecrusher Giant // Stomp": [
+			{"name": "Bonecrusher Giant // Stomp", "faceName": "Bonecrusher Giant", "layout": "adventure", "manaValue": 3, "colors": ["R"], "colorIdentity": ["R"], "type": "Creature — Giant", "identifiers": {"scryfallOracleId": "o-giant"}},
+			{"name": "Bonecrusher Giant // Stomp", "faceName": "Stomp", "layout": "adventure", "manaValue": 3, "colors": ["R"], "colorIdentity": ["R"], "type": "Instant — Adventure", "identifiers": {"scryfallOracleId": "o-giant"}}
+		],
+		"Opt": [
+			{"name": "Opt", "layout": "normal", "manaValue": 1, "colors": ["U"], "colorIdentity": ["U"], "type": "Instant", "identifiers": {"scryfallOracleId": "o-opt"}}
+		]
+	}
+}`
+
+func writeFixture(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cards.json")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return path
+}
+
+func TestLoadScryfall(t *testing.T) {
+	db, err := Load(writeFixture(t, scryfallFixture))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if db.Len() != 4 {
+		t.Errorf("expected 4 oracle cards (printings merged, tokens skipped), got %d", db.Len())
+	}
+
+	bolt, ok := db.Resolve("lightning bolt")
+	if !ok || bolt.OracleID != "o-bolt" || bolt.Rarity != "uncommon" || bolt.ManaValue != 1 {
+		t.Errorf("bolt should resolve with the newest printing's rarity: %+v", bolt)
+	}
+
+	tests := map[string]string{
+		"Fire // Ice":                            "o-fire",
+		"Fire/Ice":                               "o-fire",
+		"Fire":                                   "o-fire",
+		"Ice":                                    "o-fire",
+		"Delver of Secrets":                      "o-delver",
+		"Insectile Aberration":                   "o-delver",
+		"Lim-Dul's Vault":                        "o-vault",
+		"Lim-Dûl’s Vault":                        "o-vault",
+		"Delver of Secrets/Insectile Aberration": "o-delver",
+	}
+	for name, want := range tests {
+		card, ok := db.Resolve(name)
+		if !ok || card.OracleID != want {
+			t.Errorf("Resolve(%q): expected %s, got %+v", name, want, card)
+		}
+	}
+
+	delver, _ := db.Resolve("Delver of Secrets")
+	if !reflect.DeepEqual(delver.Colors, []string{"U"}) {
+		t.Errorf("DFC colors should come from faces: %v", delver.Colors)
+	}
+	vault, _ := db.Resolve("Lim-Dûl's Vault")
+	if !reflect.DeepEqual(vault.Colors, []string{"U", "B"}) {
+		t.Errorf("colors should be in WUBRG order: %v", vault.Colors)
+	}
+
+	if _, ok := db.Resolve("Goblin"); ok {
+		t.Error("tokens should not resolve")
+	}
+}
+
+func TestLoadMTGJSON(t *testing.T) {
+	db, err := Load(writeFixture(t, mtgjsonFixture))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	giant, ok := db.Resolve("Stomp")
+	if !ok || giant.Name != "Bonecrusher Giant // Stomp" || giant.OracleID != "o-giant" {
+		t.Fatalf("adventure face should resolve to the whole card: %+v", giant)
+	}
+	if giant.TypeLine != "Creature — Giant // Instant — Adventure" || giant.Rarity != "" {
+		t.Errorf("unexpected giant metadata: %+v", giant)
+	}
+	if _, ok := db.Resolve("Bonecrusher Giant"); !ok {
+		t.Error("front face should resolve")
+	}
+}
+
+func TestResolveAll_Unresolved(t *testing.T) {
+	db, err := Load(writeFixture(t, scryfallFixture))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	resolved, err := db.ResolveAll([]string{"Lightning Bolt", "Totally Fake Card", "Another Fake", "Lightning Bolt"})
+	var unresolved *UnresolvedError
+	if !errors.As(err, &unresolved) {
+		t.Fatalf("expected UnresolvedError, got %v", err)
+	}
+	if !reflect.DeepEqual(unresolved.Names, []string{"Another Fake", "Totally Fake Card"}) {
+		t.Errorf("unresolved names wrong: %v", unresolved.Names)
+	}
+	if resolved["Lightning Bolt"] == nil {
+		t.Error("resolvable names should still be returned")
+	}
+}
+
+func TestLoad_UnknownFormat(t *testing.T) {
+	if _, err := Load(writeFixture(t, `"just a string"`)); err == nil {
+		t.Fatal("expected error for unrecognized format")
+	}
+}
+
+func TestLoadScryfall_Images(t *testing.T) {
+	fixture := `[
+	{"oracle_id": "o-bolt", "name": "Lightning Bolt", "layout": "normal", "released_at": "2010-07-16",
+	 "image_uris": {"normal": "m10-normal", "art_crop": "m10-art"}},
+	{"oracle_id": "o-bolt", "name": "Lightning Bolt", "layout": "normal", "released_at": "2024-01-01", "promo": true,
+	 "image_uris": {"normal": "promo-normal", "art_crop": "promo-art"}},
+	{"oracle_id": "o-bolt", "name": "Lightning Bolt", "layout": "normal", "released_at": "2025-01-01", "digital": true,
+	 "image_uris": {"normal": "digital-normal", "art_crop": "digital-art"}},
+	{"oracle_id": "o-bolt", "name": "Lightning Bolt", "layout": "normal", "released_at": "2026-01-01", "image_status": "missing",
+	 "image_uris": {"normal": "missing-normal", "art_crop": "missing-art"}},
+	{"oracle_id": "o-delver", "name": "Delver of Secrets // Insectile Aberration", "layout": "transform", "released_at": "2011-09-30",
+	 "card_faces": [
+		{"name": "Delver of Secrets", "image_uris": {"normal": "delver-normal", "art_crop": "delver-art"}},
+		{"name": "Insectile Aberration", "image_uris": {"normal": "insect-normal", "art_crop": "insect-art"}}
+	 ]},
+	{"oracle_id": "o-fire", "name": "Fire // Ice", "layout": "split", "released_at": "2001-01-01",
+	 "image_uris": {"normal": "fire-normal", "art_crop": "fire-art"},
+	 "card_faces": [{"name": "Fire"}, {"name": "Ice"}]}
+]`
+	db, err := Load(writeFixture(t, fixture))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	tests := map[string][]Images{
+		"Lightning Bolt":       {{Normal: "m10-normal", ArtCrop: "m10-art"}},
+		"Insectile Aberration": {{Normal: "delver-normal", ArtCrop: "delver-art"}, {Normal: "insect-normal", ArtCrop: "insect-art"}},
+		"Ice":                  {{Normal: "fire-normal", ArtCrop: "fire-art"}},
+	}
+	for name, want := range tests {
+		card, _ := db.Resolve(name)
+		if card == nil || !reflect.DeepEqual(card.Images, want) {
+			t.Errorf("%s images: expected %+v, got %+v", name, want, card)
+		}
+	}
+
+	opt, _ := Load(writeFixture(t, mtgjsonFixture))
+	if card, _ := opt.Resolve("Opt"); card.Images != nil {
+		t.Errorf("MTGJSON cards have no images: %+v", card.Images)
+	}
+}
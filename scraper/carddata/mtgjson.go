@@ -0,0 +1,107 @@
+package carddata
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// mtgjsonCard is the subset of an MTGJSON AtomicCards face we read
+type mtgjsonCard struct {
+	Name          string            `json:"name"`
+	FaceName      string            `json:"faceName"`
+	Layout        string            `json:"layout"`
+	ManaValue     float64           `json:"manaValue"`
+	Colors        []string          `json:"colors"`
+	ColorIdentity []string          `json:"colorIdentity"`
+	Type          string            `json:"type"`
+	Side          string            `json:"side"`
+	Legalities    map[string]string `json:"legalities"`
+	Identifiers   struct {
+		ScryfallOracleID string `json:"scryfallOracleId"`
+	} `json:"identifiers"`
+}
+
+// loadMTGJSON reads the remaining members of an AtomicCards object. Only "data" is
+// used: it maps each card name to its faces. AtomicCards has no printing data, so
+// Rarity stays empty.
+func (db *DB) loadMTGJSON(decoder *json.Decoder) error {
+	foundData := false
+
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("read mtgjson key: %w", err)
+		}
+		if key != "data" {
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return fmt.Errorf("skip mtgjson %v: %w", key, err)
+			}
+			continue
+		}
+
+		foundData = true
+		if _, err := decoder.Token(); err != nil {
+			return fmt.Errorf("read mtgjson data: %w", err)
+		}
+		for decoder.More() {
+			nameToken, err := decoder.Token()
+			if err != nil {
+				return fmt.Errorf("read mtgjson card name: %w", err)
+			}
+			var faces []mtgjsonCard
+			if err := decoder.Decode(&faces); err != nil {
+				return fmt.Errorf("decode mtgjson card %v: %w", nameToken, err)
+			}
+			if card := cardFromMTGJSON(fmt.Sprint(nameToken), faces); card != nil {
+				db.add(card)
+			}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return fmt.Errorf("read end of mtgjson data: %w", err)
+		}
+	}
+
+	if !foundData {
+		return fmt.Errorf("mtgjson file has no data object")
+	}
+	return nil
+}
+
+// cardFromMTGJSON merges the faces of one AtomicCards entry into a Card
+func cardFromMTGJSON(name string, faces []mtgjsonCard) *Card {
+	if len(faces) == 0 {
+		return nil
+	}
+	front := faces[0]
+	if nonGameLayouts[front.Layout] {
+		return nil
+	}
+
+	card := &Card{
+		OracleID:      front.Identifiers.ScryfallOracleID,
+		Name:          name,
+		ManaValue:     front.ManaValue,
+		ColorIdentity: sortedColors(front.ColorIdentity),
+		Layout:        front.Layout,
+		Legalities:    normalizeLegalities(front.Legalities),
+	}
+
+	var colors, types []string
+	for _, face := range faces {
+		colors = append(colors, face.Colors...)
+		types = append(types, face.Type)
+		if face.FaceName != "" {
+			card.Faces = append(card.Faces, face.FaceName)
+		}
+	}
+	card.Colors = sortedColors(colors)
+	if len(faces) > 1 {
+		card.TypeLine = strings.Join(types, " // ")
+	} else {
+		card.TypeLine = front.Type
+	}
+
+	return card
+}
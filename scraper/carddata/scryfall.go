@@ -0,0 +1,166 @@
+package carddata
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// scryfallCard is the subset of a Scryfall card object we read
+type scryfallCard struct {
+	OracleID      string             `json:"oracle_id"`
+	Name          string             `json:"name"`
+	Layout        string             `json:"layout"`
+	CMC           float64            `json:"cmc"`
+	Colors        []string           `json:"colors"`
+	ColorIdentity []string           `json:"color_identity"`
+	TypeLine      string             `json:"type_line"`
+	Rarity        string             `json:"rarity"`
+	ReleasedAt    string             `json:"released_at"`
+	CardFaces     []scryfallFace     `json:"card_faces"`
+	Legalities    map[string]string  `json:"legalities"`
+	ImageURIs     *scryfallImageURIs `json:"image_uris"`
+	ImageStatus   string             `json:"image_status"`
+	Digital       bool               `json:"digital"`
+	Promo         bool               `json:"promo"`
+}
+
+type scryfallFace struct {
+	OracleID  string             `json:"oracle_id"`
+	Name      string             `json:"name"`
+	Colors    []string           `json:"colors"`
+	TypeLine  string             `json:"type_line"`
+	ImageURIs *scryfallImageURIs `json:"image_uris"`
+}
+
+type scryfallImageURIs struct {
+	Normal  string `json:"normal"`
+	ArtCrop string `json:"art_crop"`
+}
+
+// images returns the printing's per-face images, or nil when it has none
+func (sc *scryfallCard) images() []Images {
+	if sc.ImageStatus == "missing" || sc.ImageStatus == "placeholder" {
+		return nil
+	}
+	if sc.ImageURIs != nil {
+		return []Images{{Normal: sc.ImageURIs.Normal, ArtCrop: sc.ImageURIs.ArtCrop}}
+	}
+	var images []Images
+	for _, face := range sc.CardFaces {
+		if face.ImageURIs != nil {
+			images = append(images, Images{Normal: face.ImageURIs.Normal, ArtCrop: face.ImageURIs.ArtCrop})
+		}
+	}
+	return images
+}
+
+// imageRank orders printings for image selection: paper non-promo printings beat
+// promos, which beat digital-only printings. Ties go to the newest release.
+func (sc *scryfallCard) imageRank() int {
+	switch {
+	case sc.Digital:
+		return 0
+	case sc.Promo:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// nonGameLayouts are Scryfall layouts that never appear in a decklist
+var nonGameLayouts = map[string]bool{
+	"token":              true,
+	"double_faced_token": true,
+	"emblem":             true,
+	"art_series":         true,
+	"vanguard":           true,
+	"scheme":             true,
+	"planar":             true,
+}
+
+// loadScryfall reads the remaining elements of a Scryfall bulk array, one printing
+// at a time. Printings are merged per oracle ID; rarity comes from the newest printing
+// and images from the newest paper, non-promo printing that has them.
+func (db *DB) loadScryfall(decoder *json.Decoder) error {
+	byOracle := make(map[string]*Card)
+	released := make(map[string]string)
+	imagePrinting := make(map[string]*scryfallCard)
+
+	for decoder.More() {
+		var sc scryfallCard
+		if err := decoder.Decode(&sc); err != nil {
+			return fmt.Errorf("decode scryfall card: %w", err)
+		}
+		if nonGameLayouts[sc.Layout] {
+			continue
+		}
+
+		oracleID := sc.OracleID
+		if oracleID == "" && len(sc.CardFaces) > 0 {
+			oracleID = sc.CardFaces[0].OracleID
+		}
+		if oracleID == "" {
+			continue
+		}
+
+		if card, exists := byOracle[oracleID]; exists {
+			if sc.ReleasedAt > released[oracleID] {
+				card.Rarity = sc.Rarity
+				released[oracleID] = sc.ReleasedAt
+			}
+			if images := sc.images(); images != nil && betterImagePrinting(&sc, imagePrinting[oracleID]) {
+				card.Images = images
+				imagePrinting[oracleID] = &sc
+			}
+			continue
+		}
+
+		card := &Card{
+			OracleID:      oracleID,
+			Name:          sc.Name,
+			ManaValue:     sc.CMC,
+			Colors:        sortedColors(sc.Colors),
+			ColorIdentity: sortedColors(sc.ColorIdentity),
+			TypeLine:      sc.TypeLine,
+			Rarity:        sc.Rarity,
+			Layout:        sc.Layout,
+			Legalities:    normalizeLegalities(sc.Legalities),
+			Images:        sc.images(),
+		}
+		if card.Images != nil {
+			imagePrinting[oracleID] = &sc
+		}
+
+		var faceColors []string
+		for _, face := range sc.CardFaces {
+			card.Faces = append(card.Faces, face.Name)
+			faceColors = append(faceColors, face.Colors...)
+		}
+		if sc.Colors == nil {
+			card.Colors = sortedColors(faceColors)
+		}
+		if card.TypeLine == "" && len(sc.CardFaces) > 0 {
+			card.TypeLine = sc.CardFaces[0].TypeLine
+		}
+
+		byOracle[oracleID] = card
+		released[oracleID] = sc.ReleasedAt
+		db.add(card)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("read end of scryfall array: %w", err)
+	}
+	return nil
+}
+
+// betterImagePrinting reports whether candidate's images should replace current's
+func betterImagePrinting(candidate, current *scryfallCard) bool {
+	if current == nil {
+		return true
+	}
+	if candidate.imageRank() != current.imageRank() {
+		return candidate.imageRank() > current.imageRank()
+	}
+	return candidate.ReleasedAt > current.ReleasedAt
+}
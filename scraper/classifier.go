@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const archetypeRulesFile = "archetype-rules.json"
+
+// ArchetypeRules is the versioned rule set in data/archetype-rules.json
+type ArchetypeRules struct {
+	Version      int               `json:"version"`
+	ColorSources map[string]string `json:"colorSources"`
+	Archetypes   []ArchetypeRule   `json:"archetypes"`
+}
+
+// ArchetypeRule assigns an archetype when a deck plays at least MinSignatures of the
+// signature cards (each with at least MinCopies in the main deck) and, when Colors is
+// set, the deck's colors are exactly Colors. Rules are tried in file order.
+type ArchetypeRule struct {
+	Name           string   `json:"name"`
+	Colors         string   `json:"colors,omitempty"`
+	SignatureCards []string `json:"signatureCards"`
+	MinSignatures  int      `json:"minSignatures"`
+	MinCopies      int      `json:"minCopies,omitempty"`
+}
+
+// DeckClassification records the rule-based archetype next to the player-typed label
+type DeckClassification struct {
+	PlayerName string `json:"playerName"`
+	Label      string `json:"label"`
+	Archetype  string `json:"archetype"`
+	Colors     string `json:"colors"`
+}
+
+// ClassificationReport summarises one classification run for a tournament
+type ClassificationReport struct {
+	RulesVersion  int                  `json:"rulesVersion"`
+	Decks         int                  `json:"decks"`
+	Classified    int                  `json:"classified"`
+	Agreements    int                  `json:"agreements"`
+	Disagreements []DeckClassification `json:"disagreements"`
+	Unclassified  []DeckClassification `json:"unclassified"`
+}
+
+// loadArchetypeRules reads and validates the classifier rules file
+func loadArchetypeRules(path string) (*ArchetypeRules, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules %s: %w", path, err)
+	}
+
+	var rules ArchetypeRules
+	if err := json.Unmarshal(bytes, &rules); err != nil {
+		return nil, fmt.Errorf("parse rules %s: %w", path, err)
+	}
+
+	if rules.Version <= 0 {
+		return nil, fmt.Errorf("rules %s: missing or invalid version", path)
+	}
+	for i, rule := range rules.Archetypes {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rules %s: archetype %d has no name", path, i)
+		}
+		if len(rule.SignatureCards) == 0 {
+			return nil, fmt.Errorf("rules %s: archetype %q has no signature cards", path, rule.Name)
+		}
+		if rule.MinSignatures <= 0 || rule.MinSignatures > len(rule.SignatureCards) {
+			return nil, fmt.Errorf("rules %s: archetype %q minSignatures must be between 1 and %d", path, rule.Name, len(rule.SignatureCards))
+		}
+	}
+
+	return &rules, nil
+}
+
+// normalizeColors returns the distinct WUBRG letters in s, in WUBRG order
+func normalizeColors(s string) string {
+	s = strings.ToUpper(s)
+	var out strings.Builder
+	for _, c := range "WUBRG" {
+		if strings.ContainsRune(s, c) {
+			out.WriteRune(c)
+		}
+	}
+	return out.String()
+}
+
+// deckColors infers a deck's colors from the main-deck cards listed in colorSources
+func (r *ArchetypeRules) deckColors(deck DeckInfo) string {
+	var colors string
+	for _, card := range deck.MainDeck {
+		colors += r.ColorSources[card.Name]
+	}
+	return normalizeColors(colors)
+}
+
+// classify returns the first rule-matching archetype for deck, or "" if none match
+func (r *ArchetypeRules) classify(deck DeckInfo) string {
+	counts := cardCounts(deck.MainDeck)
+	colors := r.deckColors(deck)
+
+	for _, rule := range r.Archetypes {
+		if rule.Colors != "" && normalizeColors(rule.Colors) != colors {
+			continue
+		}
+
+		minCopies := rule.MinCopies
+		if minCopies <= 0 {
+			minCopies = 1
+		}
+
+		matched := 0
+		for _, name := range rule.SignatureCards {
+			if counts[name] >= minCopies {
+				matched++
+			}
+		}
+		if matched >= rule.MinSignatures {
+			return rule.Name
+		}
+	}
+
+	return ""
+}
+
+// classifyDecklists sets ClassifiedArchetype on every deck with cards, leaving the
+// player-typed Archetype untouched, and reports where the two disagree.
+func classifyDecklists(decklists []DeckInfo, rules *ArchetypeRules) *ClassificationReport {
+	report := &ClassificationReport{
+		RulesVersion:  rules.Version,
+		Disagreements: []DeckClassification{},
+		Unclassified:  []DeckClassification{},
+	}
+
+	for i := range decklists {
+		deck := &decklists[i]
+		if len(deck.MainDeck) == 0 {
+			continue
+		}
+		report.Decks++
+
+		deck.ClassifiedArchetype = rules.classify(*deck)
+		entry := DeckClassification{
+			PlayerName: deck.PlayerName,
+			Label:      deck.Archetype,
+			Archetype:  deck.ClassifiedArchetype,
+			Colors:     rules.deckColors(*deck),
+		}
+
+		switch {
+		case deck.ClassifiedArchetype == "":
+			report.Unclassified = append(report.Unclassified, entry)
+		case strings.EqualFold(strings.TrimSpace(deck.Archetype), deck.ClassifiedArchetype):
+			report.Classified++
+			report.Agreements++
+		default:
+			report.Classified++
+			report.Disagreements = append(report.Disagreements, entry)
+		}
+	}
+
+	sort.Slice(report.Disagreements, func(i, j int) bool {
+		return report.Disagreements[i].PlayerName < report.Disagreements[j].PlayerName
+	})
+	sort.Slice(report.Unclassified, func(i, j int) bool {
+		return report.Unclassified[i].PlayerName < report.Unclassified[j].PlayerName
+	})
+
+	return report
+}
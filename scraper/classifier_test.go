@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testRules() *ArchetypeRules {
+	return &ArchetypeRules{
+		Version: 3,
+		ColorSources: map[string]string{
+			"Island":      "U",
+			"Mountain":    "R",
+			"Forest":      "G",
+			"Steam Vents": "UR",
+		},
+		Archetypes: []ArchetypeRule{
+			{Name: "Izzet Lessons", Colors: "UR", SignatureCards: []string{"Gran-Gran", "Accumulate Wisdom"}, MinSignatures: 2},
+			{Name: "Izzet Prowess", Colors: "RU", SignatureCards: []string{"Stormchaser's Talent", "Opt", "Flow State"}, MinSignatures: 2, MinCopies: 2},
+			{Name: "Temur Prowess", Colors: "URG", SignatureCards: []string{"Stormchaser's Talent", "Opt"}, MinSignatures: 2},
+		},
+	}
+}
+
+func TestClassify(t *testing.T) {
+	rules := testRules()
+
+	tests := []struct {
+		name string
+		deck DeckInfo
+		want string
+	}{
+		{
+			name: "first matching rule wins",
+			deck: DeckInfo{MainDeck: []CardInfo{{Quantity: 2, Name: "Gran-Gran"}, {Quantity: 4, Name: "Accumulate Wisdom"}, {Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Flow State"}, {Quantity: 4, Name: "Steam Vents"}}},
+			want: "Izzet Lessons",
+		},
+		{
+			name: "min copies threshold",
+			deck: DeckInfo{MainDeck: []CardInfo{{Quantity: 1, Name: "Stormchaser's Talent"}, {Quantity: 4, Name: "Opt"}, {Quantity: 8, Name: "Island"}, {Quantity: 8, Name: "Mountain"}}},
+			want: "",
+		},
+		{
+			name: "colors must match exactly",
+			deck: DeckInfo{MainDeck: []CardInfo{{Quantity: 4, Name: "Stormchaser's Talent"}, {Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Steam Vents"}, {Quantity: 1, Name: "Forest"}}},
+			want: "Temur Prowess",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := rules.classify(tt.deck); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestClassifyDecklists(t *testing.T) {
+	decklists := []DeckInfo{
+		{PlayerName: "Bob", Archetype: "UR Prowess", MainDeck: []CardInfo{{Quantity: 4, Name: "Stormchaser's Talent"}, {Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Steam Vents"}}},
+		{PlayerName: "Alice", Archetype: "izzet prowess", MainDeck: []CardInfo{{Quantity: 4, Name: "Flow State"}, {Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Steam Vents"}}},
+		{PlayerName: "Carol", Archetype: "Carol's Brew", MainDeck: []CardInfo{{Quantity: 20, Name: "Forest"}}},
+		{PlayerName: "Dave", Archetype: "Izzet Prowess"},
+	}
+
+	report := classifyDecklists(decklists, testRules())
+
+	if report.RulesVersion != 3 || report.Decks != 3 || report.Classified != 2 || report.Agreements != 1 {
+		t.Errorf("report counts wrong: %+v", report)
+	}
+	if len(report.Disagreements) != 1 || report.Disagreements[0].PlayerName != "Bob" || report.Disagreements[0].Label != "UR Prowess" {
+		t.Errorf("expected Bob's label to disagree: %+v", report.Disagreements)
+	}
+	if len(report.Unclassified) != 1 || report.Unclassified[0].Colors != "G" {
+		t.Errorf("expected Carol unclassified: %+v", report.Unclassified)
+	}
+	if decklists[0].Archetype != "UR Prowess" || decklists[0].ClassifiedArchetype != "Izzet Prowess" {
+		t.Errorf("original label must be kept alongside the classification: %+v", decklists[0])
+	}
+}
+
+func TestLoadArchetypeRules_Invalid(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "rules.json")
+	contents := `{"version": 1, "archetypes": [{"name": "Mono-Red", "signatureCards": ["Shock"], "minSignatures": 2}]}`
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	if _, err := loadArchetypeRules(path); err == nil {
+		t.Fatal("expected error for minSignatures above signature card count")
+	}
+}
+
+func TestLoadArchetypeRules_RepoFile(t *testing.T) {
+	rules, err := loadArchetypeRules(filepath.Join(outputDir, archetypeRulesFile))
+	if err != nil {
+		t.Fatalf("repository rules file should load: %v", err)
+	}
+	if len(rules.Archetypes) == 0 {
+		t.Error("repository rules file has no archetypes")
+	}
+}
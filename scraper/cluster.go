@@ -0,0 +1,429 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	clusterMaxIterations    = 100
+	clusterDistinctiveCards = 3
+	clusterMaxAutoK         = 8
+)
+
+// DistinctiveCard is a card played much more often inside a cluster than outside it
+type DistinctiveCard struct {
+	Name        string  `json:"name"`
+	ClusterRate float64 `json:"clusterRate"`
+	OutsideRate float64 `json:"outsideRate"`
+}
+
+// DeckCluster is one group of similar decklists
+type DeckCluster struct {
+	ID               int               `json:"id"`
+	Name             string            `json:"name"`
+	Medoid           string            `json:"medoid"`
+	Size             int               `json:"size"`
+	DistinctiveCards []DistinctiveCard `json:"distinctiveCards"`
+	Labels           map[string]int    `json:"labels"`
+	Members          []string          `json:"members"`
+}
+
+// ClusterReport is the output of the cluster command. Silhouette is the mean
+// silhouette score of the clustering, from -1 to 1; higher is better separated.
+type ClusterReport struct {
+	SchemaVersion int            `json:"schemaVersion,omitempty"`
+	TournamentID  string         `json:"tournamentId"`
+	Archetype     string         `json:"archetype,omitempty"`
+	K             int            `json:"k"`
+	Silhouette    float64        `json:"silhouette"`
+	Decks         int            `json:"decks"`
+	Clusters      []*DeckCluster `json:"clusters"`
+}
+
+// runCluster implements the cluster command
+func runCluster(args []string) error {
+	fs := flag.NewFlagSet("cluster", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Tournament ID whose decklists to cluster (required)")
+	kFlag := fs.Int("k", 0, "Number of clusters. When 0, uses the number of distinct archetype labels, or with -archetype the k with the best silhouette score.")
+	archetypeFlag := fs.String("archetype", "", "Only cluster decks with this archetype label (e.g. to find variants)")
+	outputFlag := fs.String("output", "", "Output path. Defaults to tournament-{id}-clusters.json in the data directory")
+	fs.Parse(args)
+
+	if *tournamentFlag == "" {
+		return fmt.Errorf("-tournament is required")
+	}
+
+	decklists, err := loadDecklists(*tournamentFlag)
+	if err != nil {
+		return err
+	}
+
+	var decks []DeckInfo
+	for _, deck := range decklists {
+		if len(deck.MainDeck) == 0 {
+			continue
+		}
+		if *archetypeFlag != "" && !strings.EqualFold(deck.Archetype, *archetypeFlag) {
+			continue
+		}
+		decks = append(decks, deck)
+	}
+	if len(decks) == 0 {
+		return fmt.Errorf("no decklists with cards to cluster")
+	}
+
+	// Within one label, the label count is always 1: leave k to clusterDecks
+	k := *kFlag
+	if k <= 0 && *archetypeFlag == "" {
+		labels := make(map[string]bool)
+		for _, deck := range decks {
+			labels[deck.Archetype] = true
+		}
+		k = len(labels)
+	}
+
+	if k > 0 {
+		log.Printf("Clustering %d decks into %d clusters...", len(decks), k)
+	} else {
+		log.Printf("Clustering %d decks, choosing k by silhouette score...", len(decks))
+	}
+	report := clusterDecks(decks, k)
+	log.Printf("  k = %d, silhouette %.3f", report.K, report.Silhouette)
+	report.SchemaVersion = currentSchemaVersions["clusters"]
+	report.TournamentID = *tournamentFlag
+	report.Archetype = *archetypeFlag
+
+	for _, c := range report.Clusters {
+		log.Printf("  #%d %s (%d decks)", c.ID, c.Name, c.Size)
+	}
+
+	if *outputFlag != "" {
+		return writeJSON(*outputFlag, report)
+	}
+	return saveJSON(*tournamentFlag, "clusters", report)
+}
+
+// deckVector turns a deck into a sparse card-count vector.
+// Main deck and sideboard copies of a card are separate dimensions.
+func deckVector(deck DeckInfo) map[string]float64 {
+	return weightedDeckVector(deck, 1)
+}
+
+// cosineDistance returns 1 - cosine similarity of two sparse vectors
+func cosineDistance(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for key, va := range a {
+		normA += va * va
+		dot += va * b[key]
+	}
+	for _, vb := range b {
+		normB += vb * vb
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
+
+// clusterDecks groups decks into k clusters with k-medoids. k is capped at the
+// number of distinct decks; when k is 0 it is chosen from 2 to clusterMaxAutoK by
+// the best silhouette score.
+// Decks are sorted by player name and medoids are seeded with the greedy PAM BUILD
+// step, so the result depends only on the input decks.
+func clusterDecks(decks []DeckInfo, k int) *ClusterReport {
+	decks = append([]DeckInfo(nil), decks...)
+	sort.SliceStable(decks, func(i, j int) bool {
+		return decks[i].PlayerName < decks[j].PlayerName
+	})
+
+	n := len(decks)
+	vectors := make([]map[string]float64, n)
+	for i, deck := range decks {
+		vectors[i] = deckVector(deck)
+	}
+	dist := make([][]float64, n)
+	for i := range dist {
+		dist[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			d := cosineDistance(vectors[i], vectors[j])
+			dist[i][j] = d
+			dist[j][i] = d
+		}
+	}
+
+	// Identical decks can't seed separate clusters: the extra ones would stay empty
+	distinct := 0
+	for i := 0; i < n; i++ {
+		duplicate := false
+		for j := 0; j < i && !duplicate; j++ {
+			duplicate = dist[i][j] < 1e-12
+		}
+		if !duplicate {
+			distinct++
+		}
+	}
+
+	var medoids, assignment []int
+	switch {
+	case k > 0:
+		k = min(k, distinct)
+		medoids, assignment = kMedoids(dist, k)
+	case distinct < 2:
+		k = distinct
+		medoids, assignment = kMedoids(dist, k)
+	default:
+		bestScore := math.Inf(-1)
+		for candidate := 2; candidate <= min(clusterMaxAutoK, distinct); candidate++ {
+			m, a := dropEmptyClusters(kMedoids(dist, candidate))
+			if score := silhouette(dist, a, len(m)); score > bestScore {
+				medoids, assignment, bestScore = m, a, score
+			}
+		}
+	}
+
+	// K and the silhouette score describe the clusters actually reported
+	medoids, assignment = dropEmptyClusters(medoids, assignment)
+	k = len(medoids)
+	report := &ClusterReport{K: k, Silhouette: silhouette(dist, assignment, k), Decks: n}
+	for c, medoid := range medoids {
+		cluster := &DeckCluster{
+			Medoid: decks[medoid].PlayerName,
+			Labels: make(map[string]int),
+		}
+		for i, deck := range decks {
+			if assignment[i] == c {
+				cluster.Members = append(cluster.Members, deck.PlayerName)
+				cluster.Labels[deck.Archetype]++
+			}
+		}
+		cluster.Size = len(cluster.Members)
+		cluster.DistinctiveCards = distinctiveCards(decks, assignment, c)
+		cluster.Name = clusterName(cluster)
+		report.Clusters = append(report.Clusters, cluster)
+	}
+
+	sort.SliceStable(report.Clusters, func(i, j int) bool {
+		return report.Clusters[i].Size > report.Clusters[j].Size
+	})
+	for i, cluster := range report.Clusters {
+		cluster.ID = i + 1
+	}
+
+	return report
+}
+
+// dropEmptyClusters removes medoids no deck is assigned to and renumbers the
+// assignment to match
+func dropEmptyClusters(medoids, assignment []int) ([]int, []int) {
+	sizes := make([]int, len(medoids))
+	for _, c := range assignment {
+		sizes[c]++
+	}
+	renumbered := make([]int, len(medoids))
+	var kept []int
+	for c, medoid := range medoids {
+		if sizes[c] > 0 {
+			renumbered[c] = len(kept)
+			kept = append(kept, medoid)
+		}
+	}
+	relabelled := make([]int, len(assignment))
+	for i, c := range assignment {
+		relabelled[i] = renumbered[c]
+	}
+	return kept, relabelled
+}
+
+// kMedoids runs PAM on a distance matrix, returning the medoids and each deck's cluster
+func kMedoids(dist [][]float64, k int) (medoids []int, assignment []int) {
+	n := len(dist)
+	medoids = buildMedoids(dist, k)
+	assignment = make([]int, n)
+	for iter := 0; iter < clusterMaxIterations; iter++ {
+		assignToMedoids(dist, medoids, assignment)
+
+		changed := false
+		for c := range medoids {
+			best, bestCost := medoids[c], math.Inf(1)
+			for i := 0; i < n; i++ {
+				if assignment[i] != c {
+					continue
+				}
+				cost := 0.0
+				for j := 0; j < n; j++ {
+					if assignment[j] == c {
+						cost += dist[i][j]
+					}
+				}
+				if cost < bestCost {
+					best, bestCost = i, cost
+				}
+			}
+			if best != medoids[c] {
+				medoids[c] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	assignToMedoids(dist, medoids, assignment)
+	return medoids, assignment
+}
+
+// silhouette returns the mean silhouette score of a clustering: for each deck,
+// (b - a) / max(a, b) where a is its mean distance to its own cluster and b to the
+// nearest other cluster. Decks alone in their cluster score 0.
+func silhouette(dist [][]float64, assignment []int, k int) float64 {
+	n := len(assignment)
+	if k < 2 || n == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for i := 0; i < n; i++ {
+		sums := make([]float64, k)
+		counts := make([]int, k)
+		for j := 0; j < n; j++ {
+			if j != i {
+				sums[assignment[j]] += dist[i][j]
+				counts[assignment[j]]++
+			}
+		}
+		own := assignment[i]
+		if counts[own] == 0 {
+			continue
+		}
+		a := sums[own] / float64(counts[own])
+		b := math.Inf(1)
+		for c := 0; c < k; c++ {
+			if c != own && counts[c] > 0 {
+				b = math.Min(b, sums[c]/float64(counts[c]))
+			}
+		}
+		if math.IsInf(b, 1) || math.Max(a, b) == 0 {
+			continue
+		}
+		total += (b - a) / math.Max(a, b)
+	}
+	return total / float64(n)
+}
+
+// buildMedoids picks k initial medoids greedily: the most central deck first, then
+// whichever deck most reduces the total distance to the nearest medoid.
+func buildMedoids(dist [][]float64, k int) []int {
+	n := len(dist)
+	nearest := make([]float64, n)
+	for i := range nearest {
+		nearest[i] = math.Inf(1)
+	}
+
+	var medoids []int
+	chosen := make([]bool, n)
+	for len(medoids) < k {
+		best, bestCost := -1, math.Inf(1)
+		for candidate := 0; candidate < n; candidate++ {
+			if chosen[candidate] {
+				continue
+			}
+			cost := 0.0
+			for j := 0; j < n; j++ {
+				cost += math.Min(nearest[j], dist[candidate][j])
+			}
+			if cost < bestCost {
+				best, bestCost = candidate, cost
+			}
+		}
+
+		medoids = append(medoids, best)
+		chosen[best] = true
+		for j := 0; j < n; j++ {
+			nearest[j] = math.Min(nearest[j], dist[best][j])
+		}
+	}
+
+	return medoids
+}
+
+// assignToMedoids sets assignment[i] to the index of the medoid nearest deck i.
+// Ties go to the earlier medoid.
+func assignToMedoids(dist [][]float64, medoids []int, assignment []int) {
+	for i := range assignment {
+		best := 0
+		for c, medoid := range medoids {
+			if dist[i][medoid] < dist[i][medoids[best]] {
+				best = c
+			}
+		}
+		assignment[i] = best
+	}
+}
+
+// distinctiveCards ranks cards by how much more often decks in cluster c play them
+// (in main deck or sideboard) than decks outside it
+func distinctiveCards(decks []DeckInfo, assignment []int, c int) []DistinctiveCard {
+	inside := make(map[string]int)
+	outside := make(map[string]int)
+	insideTotal, outsideTotal := 0, 0
+
+	for i, deck := range decks {
+		played := make(map[string]bool)
+		for _, card := range deck.MainDeck {
+			played[card.Name] = true
+		}
+		for _, card := range deck.Sideboard {
+			played[card.Name] = true
+		}
+
+		counts := outside
+		if assignment[i] == c {
+			counts = inside
+			insideTotal++
+		} else {
+			outsideTotal++
+		}
+		for name := range played {
+			counts[name]++
+		}
+	}
+
+	var cards []DistinctiveCard
+	for name, count := range inside {
+		card := DistinctiveCard{Name: name, ClusterRate: float64(count) / float64(insideTotal) * 100}
+		if outsideTotal > 0 {
+			card.OutsideRate = float64(outside[name]) / float64(outsideTotal) * 100
+		}
+		cards = append(cards, card)
+	}
+
+	sort.Slice(cards, func(i, j int) bool {
+		di := cards[i].ClusterRate - cards[i].OutsideRate
+		dj := cards[j].ClusterRate - cards[j].OutsideRate
+		if di != dj {
+			return di > dj
+		}
+		return cards[i].Name < cards[j].Name
+	})
+
+	if len(cards) > clusterDistinctiveCards {
+		cards = cards[:clusterDistinctiveCards]
+	}
+	return cards
+}
+
+// clusterName joins the cluster's most distinctive card names
+func clusterName(cluster *DeckCluster) string {
+	names := make([]string, len(cluster.DistinctiveCards))
+	for i, card := range cluster.DistinctiveCards {
+		names[i] = card.Name
+	}
+	return strings.Join(names, " / ")
+}
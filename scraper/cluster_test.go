@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCosineDistance(t *testing.T) {
+	a := map[string]float64{"main:Opt": 4, "main:Shock": 4}
+	if d := cosineDistance(a, a); math.Abs(d) > 1e-9 {
+		t.Errorf("identical vectors should have distance 0, got %v", d)
+	}
+	if d := cosineDistance(a, map[string]float64{"main:Forest": 20}); d != 1 {
+		t.Errorf("disjoint vectors should have distance 1, got %v", d)
+	}
+	if d := cosineDistance(a, map[string]float64{}); d != 1 {
+		t.Errorf("empty vector should have distance 1, got %v", d)
+	}
+}
+
+func TestClusterDecks(t *testing.T) {
+	izzet := func(player, splash string) DeckInfo {
+		deck := DeckInfo{
+			PlayerName: player,
+			Archetype:  "Izzet Prowess",
+			MainDeck:   []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Stormchaser's Talent"}, {Quantity: 8, Name: "Island"}},
+		}
+		if splash != "" {
+			deck.MainDeck = append(deck.MainDeck, CardInfo{Quantity: 4, Name: splash})
+		}
+		return deck
+	}
+	green := func(player string) DeckInfo {
+		return DeckInfo{
+			PlayerName: player,
+			Archetype:  "Mono-Green Landfall",
+			MainDeck:   []CardInfo{{Quantity: 4, Name: "Llanowar Elves"}, {Quantity: 18, Name: "Forest"}},
+		}
+	}
+
+	decks := []DeckInfo{
+		green("Gina"), izzet("Bob", ""), izzet("Alice", ""), green("Hank"),
+		izzet("Carol", "Slickshot Show-Off"), green("Ivy"), izzet("Dave", ""),
+	}
+
+	report := clusterDecks(decks, 2)
+	if report.K != 2 || report.Decks != 7 || len(report.Clusters) != 2 {
+		t.Fatalf("unexpected report shape: %+v", report)
+	}
+
+	first, second := report.Clusters[0], report.Clusters[1]
+	if first.ID != 1 || !reflect.DeepEqual(first.Members, []string{"Alice", "Bob", "Carol", "Dave"}) {
+		t.Errorf("largest cluster should hold the Izzet decks in name order: %+v", first)
+	}
+	if first.Labels["Izzet Prowess"] != 4 {
+		t.Errorf("label mapping wrong: %v", first.Labels)
+	}
+	if !reflect.DeepEqual(second.Members, []string{"Gina", "Hank", "Ivy"}) {
+		t.Errorf("second cluster should hold the green decks: %+v", second)
+	}
+	if second.DistinctiveCards[0].Name != "Forest" || second.DistinctiveCards[0].OutsideRate != 0 {
+		t.Errorf("green cluster distinctive cards wrong: %+v", second.DistinctiveCards)
+	}
+
+	again := clusterDecks([]DeckInfo{decks[6], decks[5], decks[4], decks[3], decks[2], decks[1], decks[0]}, 2)
+	if !reflect.DeepEqual(report, again) {
+		t.Error("clustering should not depend on input order")
+	}
+}
+
+func TestClusterDecks_CapsKAtDistinctDecks(t *testing.T) {
+	deck := func(player, card string) DeckInfo {
+		return DeckInfo{PlayerName: player, Archetype: "Izzet Prowess", MainDeck: []CardInfo{{Quantity: 4, Name: card}, {Quantity: 16, Name: "Island"}}}
+	}
+	decks := []DeckInfo{deck("Alice", "Opt"), deck("Bob", "Opt"), deck("Carol", "Opt"), deck("Dave", "Shock")}
+
+	report := clusterDecks(decks, 5)
+	if report.K != 2 || len(report.Clusters) != 2 {
+		t.Fatalf("expected 2 clusters for 2 distinct decks, got k=%d: %+v", report.K, report.Clusters)
+	}
+	for _, c := range report.Clusters {
+		if c.Size == 0 || c.Name == "" {
+			t.Errorf("unexpected empty cluster: %+v", c)
+		}
+	}
+}
+
+func TestClusterDecks_ChoosesKBySilhouette(t *testing.T) {
+	// Three variants of one archetype
+	variant := func(player string, cards ...string) DeckInfo {
+		deck := DeckInfo{PlayerName: player, Archetype: "Izzet Prowess", MainDeck: []CardInfo{{Quantity: 8, Name: "Island"}}}
+		for _, card := range cards {
+			deck.MainDeck = append(deck.MainDeck, CardInfo{Quantity: 4, Name: card})
+		}
+		return deck
+	}
+	decks := []DeckInfo{
+		variant("A1", "Opt", "Shock", "Spell Pierce"), variant("A2", "Opt", "Shock", "Spell Pierce"), variant("A3", "Opt", "Shock", "Negate"),
+		variant("B1", "Monstrous Rage", "Burst Lightning", "Cori-Steel Cutter"), variant("B2", "Monstrous Rage", "Burst Lightning", "Cori-Steel Cutter"),
+		variant("C1", "Stormchaser's Talent", "Slickshot Show-Off", "Vivi Ornitier"), variant("C2", "Stormchaser's Talent", "Slickshot Show-Off", "Vivi Ornitier"),
+	}
+
+	report := clusterDecks(decks, 0)
+	if report.K != 3 {
+		t.Errorf("expected the three variants to give k=3, got %d (silhouette %.3f)", report.K, report.Silhouette)
+	}
+	if report.Silhouette <= 0 {
+		t.Errorf("expected a positive silhouette, got %v", report.Silhouette)
+	}
+}
+
+func TestDropEmptyClusters(t *testing.T) {
+	medoids, assignment := dropEmptyClusters([]int{0, 5, 3}, []int{0, 2, 2, 0})
+	if !reflect.DeepEqual(medoids, []int{0, 3}) || !reflect.DeepEqual(assignment, []int{0, 1, 1, 0}) {
+		t.Errorf("expected medoids [0 3] and assignment [0 1 1 0], got %v %v", medoids, assignment)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// command is an offline subcommand that works on previously scraped data in outputDir
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"as-of":         {"Rebuild stats and standings as of a history snapshot or round", runAsOf},
+	"card-images":   {"Build the card image manifest from a local Scryfall bulk file", runCardImages},
+	"cluster":       {"Cluster a tournament's decklists by card contents", runCluster},
+	"cooccurrence":  {"Export card co-occurrence counts and lift/PMI as JSON, GraphML and DOT", runCooccurrence},
+	"diff":          {"Compare two decks or an archetype's average deck across tournaments", runDiff},
+	"diff-scrape":   {"Compare two versions of a tournament's matches and decklists", runDiffScrape},
+	"duplicates":    {"Group identical and near-identical 75s, within a tournament or across the registry", runDuplicates},
+	"export-decks":  {"Export decks as MTG Arena, MTGO .dek and Cockatrice .cod files", runExportDecks},
+	"export-sqlite": {"Export every tournament to a normalized SQLite database", runExportSQLite},
+	"export-tables": {"Export matches, player records and deck cards as CSV and Parquet", runExportTables},
+	"history":       {"List a tournament's history snapshots and what each one changed", runHistory},
+	"import-decks":  {"Import Arena/MTGO text, .dek or CSV decklists into a tournament", runImportDecks},
+	"migrate":       {"Upgrade data files to the current schema versions", runMigrate},
+	"publish":       {"Upload the registry and data files to S3 or an S3-compatible store", runPublish},
+	"schema":        {"Write JSON Schemas for the data files, generated from the Go types", runSchema},
+	"similar":       {"List the decks closest to a player's deck", runSimilar},
+	"stream":        {"Write match results as NDJSON, appending new or corrected results with -watch", runStream},
+	"validate":      {"Check the data files against the generated JSON Schemas", runValidate},
+}
+
+// runCommand dispatches to a subcommand, exiting on unknown names or failure
+func runCommand(name string, args []string) {
+	if name == "help" {
+		fmt.Print(commandUsage())
+		return
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q.\n\n%s", name, commandUsage())
+		os.Exit(2)
+	}
+
+	if err := cmd.run(args); err != nil {
+		log.Fatalf("%s failed: %v", name, err)
+	}
+}
+
+// commandUsage lists the available subcommands
+func commandUsage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("Usage: scraper [flags]            scrape tournaments from the registry\n")
+	b.WriteString("       scraper <command> [flags]  run an offline command\n\nCommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-16s %s\n", name, commands[name].summary)
+	}
+	return b.String()
+}
+
+// loadDecklists reads a tournament's saved decklists file
+func loadDecklists(tournamentID string) ([]DeckInfo, error) {
+	var decklists []DeckInfo
+	if err := loadJSON(tournamentID, "decklists", &decklists); err != nil {
+		return nil, err
+	}
+	return decklists, nil
+}
+
+// loadMatches reads a tournament's saved matches file
+func loadMatches(tournamentID string) (map[int][]Match, error) {
+	var matches map[int][]Match
+	if err := loadJSON(tournamentID, "matches", &matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CardNode is one card in a co-occurrence graph
+type CardNode struct {
+	Name  string  `json:"name"`
+	Decks int     `json:"decks"`
+	Rate  float64 `json:"rate"`
+}
+
+// CardPair is how often two cards are played in the same deck. Lift is the
+// observed co-occurrence over what independent cards would give; PMI is log2(lift)
+// and NPMI scales it to [-1, 1], where 1 means the cards only appear together.
+type CardPair struct {
+	A       string  `json:"a"`
+	B       string  `json:"b"`
+	Decks   int     `json:"decks"`
+	Support float64 `json:"support"`
+	Lift    float64 `json:"lift"`
+	PMI     float64 `json:"pmi"`
+	NPMI    float64 `json:"npmi"`
+}
+
+// CooccurrenceGraph is the card co-occurrence graph of a set of decks
+type CooccurrenceGraph struct {
+	Decks int        `json:"decks"`
+	Cards []CardNode `json:"cards"`
+	Pairs []CardPair `json:"pairs"`
+}
+
+// TournamentCooccurrence holds the overall graph and one graph per archetype
+type TournamentCooccurrence struct {
+	SchemaVersion int                           `json:"schemaVersion,omitempty"`
+	TournamentID  string                        `json:"tournamentId"`
+	MinDecks      int                           `json:"minDecks"`
+	Sideboard     bool                          `json:"sideboard"`
+	Overall       *CooccurrenceGraph            `json:"overall"`
+	Archetypes    map[string]*CooccurrenceGraph `json:"archetypes"`
+}
+
+// runCooccurrence implements the cooccurrence command
+func runCooccurrence(args []string) error {
+	fs := flag.NewFlagSet("cooccurrence", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Tournament ID whose decklists to analyse (required)")
+	archetypeFlag := fs.String("archetype", "", "Export this archetype's graph as GraphML/DOT instead of the overall graph")
+	minDecksFlag := fs.Int("min-decks", 3, "Only keep cards and pairs played in at least this many decks")
+	sideboardFlag := fs.Bool("sideboard", false, "Count sideboard cards as well as the main deck")
+	outputFlag := fs.String("output", outputDir, "Output directory")
+	fs.Parse(args)
+
+	if *tournamentFlag == "" {
+		return fmt.Errorf("-tournament is required")
+	}
+	if *minDecksFlag < 1 {
+		return fmt.Errorf("-min-decks must be at least 1")
+	}
+
+	decklists, err := loadDecklists(*tournamentFlag)
+	if err != nil {
+		return err
+	}
+
+	result := aggregateCooccurrence(decklists, *minDecksFlag, *sideboardFlag)
+	result.SchemaVersion = currentSchemaVersions["cooccurrence"]
+	result.TournamentID = *tournamentFlag
+	log.Printf("Overall: %d cards, %d pairs across %d decks", len(result.Overall.Cards), len(result.Overall.Pairs), result.Overall.Decks)
+
+	graph, name := result.Overall, fmt.Sprintf("tournament-%s-cooccurrence", *tournamentFlag)
+	if *archetypeFlag != "" {
+		for archetype, g := range result.Archetypes {
+			if strings.EqualFold(archetype, *archetypeFlag) {
+				graph = g
+			}
+		}
+		if graph == result.Overall {
+			return fmt.Errorf("no %s decklists in tournament %s", *archetypeFlag, *tournamentFlag)
+		}
+		name += "-" + fileSlug(*archetypeFlag)
+	}
+
+	if err := os.MkdirAll(*outputFlag, 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	if err := writeJSON(filepath.Join(*outputFlag, fmt.Sprintf("tournament-%s-cooccurrence.json", *tournamentFlag)), result); err != nil {
+		return err
+	}
+
+	graphML, err := formatGraphML(graph)
+	if err != nil {
+		return fmt.Errorf("render graphml: %w", err)
+	}
+	for path, data := range map[string][]byte{
+		filepath.Join(*outputFlag, name+".graphml"): graphML,
+		filepath.Join(*outputFlag, name+".dot"):     []byte(formatDOT(graph)),
+	} {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+		log.Printf("    Saved %s", path)
+	}
+	return nil
+}
+
+// aggregateCooccurrence builds the overall graph and one graph per archetype.
+// Decks without a main deck are skipped.
+func aggregateCooccurrence(decklists []DeckInfo, minDecks int, sideboard bool) *TournamentCooccurrence {
+	var all [][]string
+	byArchetype := make(map[string][][]string)
+	for _, deck := range decklists {
+		if len(deck.MainDeck) == 0 {
+			continue
+		}
+		cards := deckCardSet(deck, sideboard)
+		all = append(all, cards)
+		byArchetype[deck.Archetype] = append(byArchetype[deck.Archetype], cards)
+	}
+
+	result := &TournamentCooccurrence{
+		MinDecks:   minDecks,
+		Sideboard:  sideboard,
+		Overall:    cooccurrenceGraph(all, minDecks),
+		Archetypes: make(map[string]*CooccurrenceGraph),
+	}
+	for archetype, decks := range byArchetype {
+		result.Archetypes[archetype] = cooccurrenceGraph(decks, minDecks)
+	}
+	return result
+}
+
+// deckCardSet returns the sorted distinct card names a deck plays
+func deckCardSet(deck DeckInfo, sideboard bool) []string {
+	seen := make(map[string]bool)
+	for _, card := range deck.MainDeck {
+		seen[card.Name] = true
+	}
+	if sideboard {
+		for _, card := range deck.Sideboard {
+			seen[card.Name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cooccurrenceGraph counts cards and card pairs over decks (each a sorted card set)
+// and scores every pair played together in at least minDecks decks
+func cooccurrenceGraph(decks [][]string, minDecks int) *CooccurrenceGraph {
+	type pairKey struct{ a, b string }
+	cardDecks := make(map[string]int)
+	pairDecks := make(map[pairKey]int)
+	for _, cards := range decks {
+		for i, a := range cards {
+			cardDecks[a]++
+			for _, b := range cards[i+1:] {
+				pairDecks[pairKey{a, b}]++
+			}
+		}
+	}
+
+	total := float64(len(decks))
+	graph := &CooccurrenceGraph{Decks: len(decks), Cards: []CardNode{}, Pairs: []CardPair{}}
+	for name, count := range cardDecks {
+		if count >= minDecks {
+			graph.Cards = append(graph.Cards, CardNode{Name: name, Decks: count, Rate: float64(count) / total})
+		}
+	}
+	for key, count := range pairDecks {
+		if count < minDecks {
+			continue
+		}
+		support := float64(count) / total
+		lift := support / (float64(cardDecks[key.a]) / total * float64(cardDecks[key.b]) / total)
+		pmi := math.Log2(lift)
+		npmi := 1.0
+		if support < 1 {
+			npmi = pmi / -math.Log2(support)
+		}
+		graph.Pairs = append(graph.Pairs, CardPair{A: key.a, B: key.b, Decks: count, Support: support, Lift: lift, PMI: pmi, NPMI: npmi})
+	}
+
+	sort.Slice(graph.Cards, func(i, j int) bool {
+		if graph.Cards[i].Decks != graph.Cards[j].Decks {
+			return graph.Cards[i].Decks > graph.Cards[j].Decks
+		}
+		return graph.Cards[i].Name < graph.Cards[j].Name
+	})
+	sort.Slice(graph.Pairs, func(i, j int) bool {
+		pi, pj := graph.Pairs[i], graph.Pairs[j]
+		if pi.NPMI != pj.NPMI {
+			return pi.NPMI > pj.NPMI
+		}
+		if pi.Decks != pj.Decks {
+			return pi.Decks > pj.Decks
+		}
+		if pi.A != pj.A {
+			return pi.A < pj.A
+		}
+		return pi.B < pj.B
+	})
+	return graph
+}
+
+// graphMLDocument is the subset of GraphML that Gephi reads: typed attribute keys,
+// labelled nodes and weighted undirected edges
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// formatGraphML renders a graph as GraphML. Nodes are cards; edge weight is the
+// number of decks playing both cards.
+func formatGraphML(graph *CooccurrenceGraph) ([]byte, error) {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "decks", For: "node", Name: "decks", Type: "int"},
+			{ID: "weight", For: "edge", Name: "weight", Type: "double"},
+			{ID: "lift", For: "edge", Name: "lift", Type: "double"},
+			{ID: "npmi", For: "edge", Name: "npmi", Type: "double"},
+		},
+		Graph: graphMLGraph{EdgeDefault: "undirected"},
+	}
+
+	ids := make(map[string]string, len(graph.Cards))
+	for i, card := range graph.Cards {
+		ids[card.Name] = fmt.Sprintf("n%d", i)
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID:   ids[card.Name],
+			Data: []graphMLData{{"label", card.Name}, {"decks", strconv.Itoa(card.Decks)}},
+		})
+	}
+	for _, pair := range graph.Pairs {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: ids[pair.A],
+			Target: ids[pair.B],
+			Data: []graphMLData{
+				{"weight", strconv.Itoa(pair.Decks)},
+				{"lift", formatFloat(pair.Lift)},
+				{"npmi", formatFloat(pair.NPMI)},
+			},
+		})
+	}
+	return marshalXMLDocument(doc)
+}
+
+// formatDOT renders a graph in Graphviz DOT
+func formatDOT(graph *CooccurrenceGraph) string {
+	var b strings.Builder
+	b.WriteString("graph cooccurrence {\n")
+	for _, card := range graph.Cards {
+		fmt.Fprintf(&b, "  %s [decks=%d];\n", strconv.Quote(card.Name), card.Decks)
+	}
+	for _, pair := range graph.Pairs {
+		fmt.Fprintf(&b, "  %s -- %s [weight=%d, lift=%s, npmi=%s];\n",
+			strconv.Quote(pair.A), strconv.Quote(pair.B), pair.Decks, formatFloat(pair.Lift), formatFloat(pair.NPMI))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
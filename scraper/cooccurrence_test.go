@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/xml"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCooccurrenceGraph(t *testing.T) {
+	decks := [][]string{
+		{"Opt", "Shock"},
+		{"Opt", "Shock"},
+		{"Llanowar Elves", "Opt"},
+		{"Llanowar Elves"},
+	}
+	graph := cooccurrenceGraph(decks, 1)
+
+	if graph.Decks != 4 || len(graph.Cards) != 3 || graph.Cards[0].Name != "Opt" || graph.Cards[0].Decks != 3 {
+		t.Fatalf("unexpected cards: %+v", graph.Cards)
+	}
+	if len(graph.Pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %+v", graph.Pairs)
+	}
+
+	// Opt+Shock: support 2/4, lift 0.5 / (0.75 * 0.5)
+	pair := graph.Pairs[0]
+	if pair.A != "Opt" || pair.B != "Shock" || pair.Decks != 2 {
+		t.Fatalf("strongest pair should be Opt+Shock: %+v", pair)
+	}
+	wantLift := 0.5 / (0.75 * 0.5)
+	if math.Abs(pair.Lift-wantLift) > 1e-9 || math.Abs(pair.PMI-math.Log2(wantLift)) > 1e-9 {
+		t.Errorf("unexpected lift/pmi: %+v", pair)
+	}
+	if math.Abs(pair.NPMI-math.Log2(wantLift)) > 1e-9 {
+		t.Errorf("npmi should be pmi / -log2(0.5): %+v", pair)
+	}
+
+	if got := cooccurrenceGraph(decks, 2); len(got.Pairs) != 1 || len(got.Cards) != 3 {
+		t.Errorf("min decks should drop rare pairs: %+v", got)
+	}
+	if got := cooccurrenceGraph([][]string{{"A", "B"}, {"A", "B"}}, 1); got.Pairs[0].NPMI != 1 {
+		t.Errorf("cards always played together should have npmi 1: %+v", got.Pairs)
+	}
+}
+
+func TestAggregateCooccurrence(t *testing.T) {
+	decklists := []DeckInfo{
+		{PlayerName: "Alice", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Shock"}}, Sideboard: []CardInfo{{Quantity: 2, Name: "Negate"}}},
+		{PlayerName: "Bob", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 2, Name: "Shock"}}},
+		{PlayerName: "Carol", Archetype: "Mono-G", MainDeck: []CardInfo{{Quantity: 4, Name: "Llanowar Elves"}}},
+		{PlayerName: "No List", Archetype: "Unknown"},
+	}
+
+	result := aggregateCooccurrence(decklists, 1, false)
+	if result.Overall.Decks != 3 || len(result.Archetypes) != 2 || result.Archetypes["Izzet"].Decks != 2 {
+		t.Errorf("unexpected scopes: overall %d decks, archetypes %v", result.Overall.Decks, result.Archetypes)
+	}
+	for _, card := range result.Overall.Cards {
+		if card.Name == "Negate" {
+			t.Error("sideboard cards should be ignored by default")
+		}
+	}
+
+	withSideboard := aggregateCooccurrence(decklists, 1, true)
+	if len(withSideboard.Archetypes["Izzet"].Pairs) != 3 {
+		t.Errorf("expected Opt/Shock/Negate pairs with sideboard: %+v", withSideboard.Archetypes["Izzet"].Pairs)
+	}
+}
+
+func TestGraphExports(t *testing.T) {
+	graph := cooccurrenceGraph([][]string{{"Fire // Ice", "Opt"}, {"Fire // Ice", "Opt"}}, 1)
+
+	data, err := formatGraphML(graph)
+	if err != nil {
+		t.Fatalf("formatGraphML returned error: %v", err)
+	}
+	var doc graphMLDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("graphml should parse: %v", err)
+	}
+	if len(doc.Graph.Nodes) != 2 || len(doc.Graph.Edges) != 1 || doc.Graph.Edges[0].Source != "n0" || doc.Graph.Edges[0].Target != "n1" {
+		t.Errorf("unexpected graphml graph: %+v", doc.Graph)
+	}
+
+	dot := formatDOT(graph)
+	if !strings.Contains(dot, `"Fire // Ice" -- "Opt" [weight=2, lift=1.0000, npmi=1.0000];`) {
+		t.Errorf("unexpected dot:\n%s", dot)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CardDiff is one card whose count differs between two decks. Counts are
+// averages (and may be fractional) when an archetype average is compared.
+type CardDiff struct {
+	Name  string  `json:"name"`
+	A     float64 `json:"a"`
+	B     float64 `json:"b"`
+	Delta float64 `json:"delta"`
+}
+
+// DeckDiff is the output of the diff command
+type DeckDiff struct {
+	A         string     `json:"a"`
+	B         string     `json:"b"`
+	MainDeck  []CardDiff `json:"mainDeck"`
+	Sideboard []CardDiff `json:"sideboard"`
+}
+
+// comparedDeck is one side of a diff: a single deck or an archetype average
+type comparedDeck struct {
+	label     string
+	mainDeck  map[string]float64
+	sideboard map[string]float64
+}
+
+// runDiff implements the diff command
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Tournament ID of deck A (required)")
+	playerFlag := fs.String("player", "", "Player whose deck is deck A")
+	vsTournamentFlag := fs.String("vs-tournament", "", "Tournament ID of deck B. Defaults to -tournament.")
+	vsPlayerFlag := fs.String("vs-player", "", "Player whose deck is deck B. Defaults to -player.")
+	archetypeFlag := fs.String("archetype", "", "Compare this archetype's average deck in -tournament and -vs-tournament instead of players")
+	jsonFlag := fs.Bool("json", false, "Print JSON instead of text")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: scraper diff -tournament A -player P [-vs-tournament B] -vs-player Q")
+		fmt.Fprintln(fs.Output(), "       scraper diff -archetype NAME -tournament A -vs-tournament B")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *tournamentFlag == "" {
+		return fmt.Errorf("-tournament is required")
+	}
+	vsTournament := *vsTournamentFlag
+	if vsTournament == "" {
+		vsTournament = *tournamentFlag
+	}
+
+	var a, b *comparedDeck
+	var err error
+	if *archetypeFlag != "" {
+		if *playerFlag != "" || *vsPlayerFlag != "" {
+			return fmt.Errorf("-archetype cannot be combined with -player or -vs-player")
+		}
+		if a, err = loadArchetypeAverage(*tournamentFlag, *archetypeFlag); err != nil {
+			return err
+		}
+		if b, err = loadArchetypeAverage(vsTournament, *archetypeFlag); err != nil {
+			return err
+		}
+	} else {
+		if *playerFlag == "" {
+			return fmt.Errorf("-player or -archetype is required")
+		}
+		vsPlayer := *vsPlayerFlag
+		if vsPlayer == "" {
+			vsPlayer = *playerFlag
+		}
+		if vsTournament == *tournamentFlag && normalizePlayerName(vsPlayer) == normalizePlayerName(*playerFlag) {
+			return fmt.Errorf("deck B is the same as deck A; set -vs-player or -vs-tournament")
+		}
+		if a, err = loadPlayerDeck(*tournamentFlag, *playerFlag); err != nil {
+			return err
+		}
+		if b, err = loadPlayerDeck(vsTournament, vsPlayer); err != nil {
+			return err
+		}
+	}
+
+	diff := diffDecks(a, b)
+	if *jsonFlag {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	}
+	fmt.Print(formatDeckDiff(diff))
+	return nil
+}
+
+// loadPlayerDeck finds a player's deck in a tournament's decklists
+func loadPlayerDeck(tournamentID, player string) (*comparedDeck, error) {
+	decklists, err := loadDecklists(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	wanted := normalizePlayerName(player)
+	for _, deck := range decklists {
+		if normalizePlayerName(deck.PlayerName) == wanted {
+			return &comparedDeck{
+				label:     fmt.Sprintf("%s (%s, %s)", deck.PlayerName, deck.Archetype, tournamentID),
+				mainDeck:  floatCounts(cardCounts(deck.MainDeck)),
+				sideboard: floatCounts(cardCounts(deck.Sideboard)),
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("player %q not found in tournament %s", player, tournamentID)
+}
+
+// loadArchetypeAverage loads a tournament's decklists and averages one archetype
+func loadArchetypeAverage(tournamentID, archetype string) (*comparedDeck, error) {
+	decklists, err := loadDecklists(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	deck, err := archetypeAverage(decklists, archetype)
+	if err != nil {
+		return nil, fmt.Errorf("tournament %s: %w", tournamentID, err)
+	}
+	deck.label += " (" + tournamentID + ")"
+	return deck, nil
+}
+
+// archetypeAverage averages card counts over every deck of an archetype with a
+// main deck. A card missing from a deck counts as 0 copies.
+func archetypeAverage(decklists []DeckInfo, archetype string) (*comparedDeck, error) {
+	avg := &comparedDeck{mainDeck: make(map[string]float64), sideboard: make(map[string]float64)}
+	decks := 0
+	for _, deck := range decklists {
+		if len(deck.MainDeck) == 0 || !strings.EqualFold(deck.Archetype, archetype) {
+			continue
+		}
+		decks++
+		for _, card := range deck.MainDeck {
+			avg.mainDeck[card.Name] += float64(card.Quantity)
+		}
+		for _, card := range deck.Sideboard {
+			avg.sideboard[card.Name] += float64(card.Quantity)
+		}
+	}
+	if decks == 0 {
+		return nil, fmt.Errorf("no %s decklists", archetype)
+	}
+
+	for _, counts := range []map[string]float64{avg.mainDeck, avg.sideboard} {
+		for name := range counts {
+			counts[name] /= float64(decks)
+		}
+	}
+	avg.label = fmt.Sprintf("%s average of %d decks", archetype, decks)
+	return avg, nil
+}
+
+func floatCounts(counts map[string]int) map[string]float64 {
+	out := make(map[string]float64, len(counts))
+	for name, count := range counts {
+		out[name] = float64(count)
+	}
+	return out
+}
+
+// diffDecks lists the cards whose counts differ between a and b
+func diffDecks(a, b *comparedDeck) *DeckDiff {
+	return &DeckDiff{
+		A:         a.label,
+		B:         b.label,
+		MainDeck:  diffCounts(a.mainDeck, b.mainDeck),
+		Sideboard: diffCounts(a.sideboard, b.sideboard),
+	}
+}
+
+// diffCounts returns the differing cards, biggest increases first and biggest
+// decreases last. Averages are rounded to two decimals before comparing.
+func diffCounts(a, b map[string]float64) []CardDiff {
+	names := make(map[string]bool)
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+
+	diffs := []CardDiff{}
+	for name := range names {
+		countA, countB := roundCount(a[name]), roundCount(b[name])
+		if countA == countB {
+			continue
+		}
+		diffs = append(diffs, CardDiff{Name: name, A: countA, B: countB, Delta: roundCount(countB - countA)})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Delta != diffs[j].Delta {
+			return diffs[i].Delta > diffs[j].Delta
+		}
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+func roundCount(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// formatDeckDiff renders a diff as text, one "+2 Card (1 → 3)" line per card
+func formatDeckDiff(diff *DeckDiff) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "A: %s\nB: %s\n", diff.A, diff.B)
+
+	for _, section := range []struct {
+		name  string
+		cards []CardDiff
+	}{{"Main deck", diff.MainDeck}, {"Sideboard", diff.Sideboard}} {
+		fmt.Fprintf(&b, "\n%s:\n", section.name)
+		if len(section.cards) == 0 {
+			b.WriteString("  (no changes)\n")
+			continue
+		}
+		for _, card := range section.cards {
+			sign := "+"
+			if card.Delta < 0 {
+				sign = ""
+			}
+			fmt.Fprintf(&b, "  %6s  %s (%s → %s)\n", sign+formatCount(card.Delta), card.Name, formatCount(card.A), formatCount(card.B))
+		}
+	}
+	return b.String()
+}
+
+func formatCount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
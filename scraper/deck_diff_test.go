@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffCounts(t *testing.T) {
+	a := map[string]float64{"Opt": 4, "Shock": 2, "Negate": 1}
+	b := map[string]float64{"Opt": 4, "Shock": 4, "Spell Pierce": 1}
+
+	want := []CardDiff{
+		{Name: "Shock", A: 2, B: 4, Delta: 2},
+		{Name: "Spell Pierce", A: 0, B: 1, Delta: 1},
+		{Name: "Negate", A: 1, B: 0, Delta: -1},
+	}
+	if got := diffCounts(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if got := diffCounts(a, a); len(got) != 0 {
+		t.Errorf("identical decks should have no differences: %+v", got)
+	}
+}
+
+func TestArchetypeAverage(t *testing.T) {
+	decklists := []DeckInfo{
+		{PlayerName: "Alice", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 2, Name: "Shock"}}, Sideboard: []CardInfo{{Quantity: 3, Name: "Negate"}}},
+		{PlayerName: "Bob", Archetype: "izzet", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 1, Name: "Shock"}}},
+		{PlayerName: "Carol", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 2, Name: "Opt"}}},
+		{PlayerName: "No List", Archetype: "Izzet"},
+		{PlayerName: "Dave", Archetype: "Mono-G", MainDeck: []CardInfo{{Quantity: 4, Name: "Llanowar Elves"}}},
+	}
+
+	avg, err := archetypeAverage(decklists, "Izzet")
+	if err != nil {
+		t.Fatalf("archetypeAverage returned error: %v", err)
+	}
+	if !reflect.DeepEqual(avg.mainDeck, map[string]float64{"Opt": 10.0 / 3, "Shock": 1}) {
+		t.Errorf("unexpected main deck average: %v", avg.mainDeck)
+	}
+	if !reflect.DeepEqual(avg.sideboard, map[string]float64{"Negate": 1}) {
+		t.Errorf("unexpected sideboard average: %v", avg.sideboard)
+	}
+	if avg.label != "Izzet average of 3 decks" {
+		t.Errorf("unexpected label: %q", avg.label)
+	}
+
+	if _, err := archetypeAverage(decklists, "Azorius"); err == nil {
+		t.Error("expected error for an archetype with no decks")
+	}
+}
+
+func TestFormatDeckDiff(t *testing.T) {
+	diff := diffDecks(
+		&comparedDeck{label: "Izzet average of 3 decks (A)", mainDeck: map[string]float64{"Opt": 10.0 / 3}, sideboard: map[string]float64{}},
+		&comparedDeck{label: "Izzet average of 2 decks (B)", mainDeck: map[string]float64{"Opt": 4}, sideboard: map[string]float64{}},
+	)
+
+	text := formatDeckDiff(diff)
+	for _, want := range []string{"A: Izzet average of 3 decks (A)", "+0.67  Opt (3.33 → 4)", "Sideboard:\n  (no changes)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sp3c1/protour-data-viz/scraper/carddata"
+)
+
+// duplicatesFile is where duplicates -all writes its report, under outputDir
+const duplicatesFile = "duplicates.json"
+
+// defaultNearIdenticalSwaps is how many card swaps two 75s may differ by and still
+// be grouped as near-identical
+const defaultNearIdenticalSwaps = 2
+
+// DeckRef identifies one deck in a duplicate group. TournamentID is only set for
+// groups that span several tournaments.
+type DeckRef struct {
+	TournamentID string `json:"tournamentId,omitempty"`
+	PlayerName   string `json:"playerName"`
+	Archetype    string `json:"archetype"`
+}
+
+// DuplicateGroup is a set of decks with identical (Hash set) or near-identical 75s
+type DuplicateGroup struct {
+	Hash  string    `json:"hash,omitempty"`
+	Decks []DeckRef `json:"decks"`
+}
+
+// DuplicateReport is the output of the duplicates command
+type DuplicateReport struct {
+	SchemaVersion int              `json:"schemaVersion,omitempty"`
+	Tournaments   []string         `json:"tournaments"`
+	Decks         int              `json:"decks"`
+	MaxSwaps      int              `json:"maxSwaps"`
+	Identical     []DuplicateGroup `json:"identical"`
+	NearIdentical []DuplicateGroup `json:"nearIdentical"`
+}
+
+// cardListHash returns a stable fingerprint of a card list: the first 16 hex digits
+// of the SHA-256 of its "quantity name" lines sorted by name. Names are compared
+// with carddata.NormalizeName and repeated entries are summed, so ordering,
+// case and "Fire/Ice" vs "Fire // Ice" don't change the hash.
+func cardListHash(cards []CardInfo) string {
+	counts := make(map[string]int)
+	for _, card := range cards {
+		counts[carddata.NormalizeName(card.Name)] += card.Quantity
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%d %s\n", counts[name], name)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// hashDecklists sets MainHash and SideboardHash on every deck with a main deck
+func hashDecklists(decklists []DeckInfo) {
+	for i := range decklists {
+		if len(decklists[i].MainDeck) == 0 {
+			continue
+		}
+		decklists[i].MainHash = cardListHash(decklists[i].MainDeck)
+		decklists[i].SideboardHash = cardListHash(decklists[i].Sideboard)
+	}
+}
+
+// deckHash is the fingerprint of a deck's 75, or "" for a deck without cards
+func deckHash(deck DeckInfo) string {
+	if deck.MainHash == "" {
+		return ""
+	}
+	return deck.MainHash + "-" + deck.SideboardHash
+}
+
+// hashMatches reports whether a deck's stored hashes still match its cards, i.e.
+// the saved file wasn't edited or truncated since it was written
+func hashMatches(deck DeckInfo) bool {
+	return len(deck.MainDeck) > 0 &&
+		deck.MainHash == cardListHash(deck.MainDeck) &&
+		deck.SideboardHash == cardListHash(deck.Sideboard)
+}
+
+// decklistsByID indexes previously saved decks by melee.gg decklist ID, so a scrape
+// can reuse them instead of downloading them again. melee.gg offers no cheap way to
+// tell whether a list changed, so a list edited under the same ID is only picked
+// up by a scrape run with -refresh-decklists; the hashes just check the saved copy
+// against itself. Decks without an ID, without cards or whose hashes don't match
+// their cards are left out and get downloaded again.
+//
+// Only card names and quantities are kept; card metadata, the summary and
+// violations are recomputed by the scrape like they are for downloaded decks.
+func decklistsByID(decklists []DeckInfo) map[string]DeckInfo {
+	cached := make(map[string]DeckInfo)
+	for _, deck := range decklists {
+		if deck.DecklistID != "" && hashMatches(deck) {
+			cached[deck.DecklistID] = DeckInfo{
+				DecklistID: deck.DecklistID,
+				MainDeck:   bareCards(deck.MainDeck),
+				Sideboard:  bareCards(deck.Sideboard),
+			}
+		}
+	}
+	return cached
+}
+
+// bareCards copies a card list without the metadata enrichDecklists adds
+func bareCards(cards []CardInfo) []CardInfo {
+	bare := make([]CardInfo, len(cards))
+	for i, card := range cards {
+		bare[i] = CardInfo{Quantity: card.Quantity, Name: card.Name}
+	}
+	return bare
+}
+
+// identicalDecks groups decks with the same 75, largest groups first.
+// tournamentIDs[i] labels decks[i] and may be empty.
+func identicalDecks(decks []DeckInfo, tournamentIDs []string) []DuplicateGroup {
+	byHash := make(map[string][]DeckRef)
+	for i, deck := range decks {
+		if hash := deckHash(deck); hash != "" {
+			byHash[hash] = append(byHash[hash], DeckRef{TournamentID: tournamentIDs[i], PlayerName: deck.PlayerName, Archetype: deck.Archetype})
+		}
+	}
+
+	groups := []DuplicateGroup{}
+	for hash, refs := range byHash {
+		if len(refs) > 1 {
+			groups = append(groups, DuplicateGroup{Hash: hash, Decks: refs})
+		}
+	}
+	sortDuplicateGroups(groups)
+	return groups
+}
+
+// nearIdenticalDecks groups decks whose 75s differ by at most maxSwaps cards
+// (a card moved between main deck and sideboard counts as one swap). Groups are
+// transitive and only listed when they hold more than one distinct 75.
+func nearIdenticalDecks(decks []DeckInfo, tournamentIDs []string, maxSwaps int) []DuplicateGroup {
+	// Compare each distinct 75 once
+	var hashes []string
+	vectors := make(map[string]map[string]float64)
+	for _, deck := range decks {
+		hash := deckHash(deck)
+		if hash == "" {
+			continue
+		}
+		if _, seen := vectors[hash]; !seen {
+			hashes = append(hashes, hash)
+			vectors[hash] = deckVector(deck)
+		}
+	}
+	sort.Strings(hashes)
+
+	parent := make(map[string]string, len(hashes))
+	var root func(string) string
+	root = func(h string) string {
+		if parent[h] == "" || parent[h] == h {
+			return h
+		}
+		parent[h] = root(parent[h])
+		return parent[h]
+	}
+	for i, a := range hashes {
+		for _, b := range hashes[i+1:] {
+			if cardSwaps(vectors[a], vectors[b]) <= maxSwaps {
+				parent[root(b)] = root(a)
+			}
+		}
+	}
+
+	byRoot := make(map[string][]DeckRef)
+	distinct := make(map[string]map[string]bool)
+	for i, deck := range decks {
+		hash := deckHash(deck)
+		if hash == "" {
+			continue
+		}
+		r := root(hash)
+		byRoot[r] = append(byRoot[r], DeckRef{TournamentID: tournamentIDs[i], PlayerName: deck.PlayerName, Archetype: deck.Archetype})
+		if distinct[r] == nil {
+			distinct[r] = make(map[string]bool)
+		}
+		distinct[r][hash] = true
+	}
+
+	groups := []DuplicateGroup{}
+	for r, refs := range byRoot {
+		if len(distinct[r]) > 1 {
+			groups = append(groups, DuplicateGroup{Decks: refs})
+		}
+	}
+	sortDuplicateGroups(groups)
+	return groups
+}
+
+// cardSwaps is how many cards must be swapped to turn one deck vector into the
+// other: half the total absolute difference in copies
+func cardSwaps(a, b map[string]float64) int {
+	var diff float64
+	for key, va := range a {
+		if va > b[key] {
+			diff += va - b[key]
+		} else {
+			diff += b[key] - va
+		}
+	}
+	for key, vb := range b {
+		if _, shared := a[key]; !shared {
+			diff += vb
+		}
+	}
+	return int(diff+1) / 2
+}
+
+// sortDuplicateGroups orders groups largest first and the decks in each group by
+// tournament and player
+func sortDuplicateGroups(groups []DuplicateGroup) {
+	for _, g := range groups {
+		sort.Slice(g.Decks, func(i, j int) bool {
+			if g.Decks[i].TournamentID != g.Decks[j].TournamentID {
+				return g.Decks[i].TournamentID < g.Decks[j].TournamentID
+			}
+			return g.Decks[i].PlayerName < g.Decks[j].PlayerName
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].Decks) != len(groups[j].Decks) {
+			return len(groups[i].Decks) > len(groups[j].Decks)
+		}
+		return groups[i].Decks[0].PlayerName < groups[j].Decks[0].PlayerName
+	})
+}
+
+// runDuplicates implements the duplicates command
+func runDuplicates(args []string) error {
+	fs := flag.NewFlagSet("duplicates", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Tournament ID whose decklists to check")
+	allFlag := fs.Bool("all", false, "Check every registry tournament together, linking decks across tournaments")
+	maxSwapsFlag := fs.Int("max-swaps", defaultNearIdenticalSwaps, "Card swaps allowed between near-identical 75s")
+	fs.Parse(args)
+
+	if (*tournamentFlag == "") == !*allFlag {
+		return fmt.Errorf("exactly one of -tournament or -all is required")
+	}
+
+	tournamentIDs := []string{*tournamentFlag}
+	if *allFlag {
+		registry, err := loadRegistry(filepath.Join(outputDir, registryFile))
+		if err != nil {
+			return err
+		}
+		tournamentIDs = nil
+		for _, t := range registry {
+			tournamentIDs = append(tournamentIDs, t.ID)
+		}
+	}
+
+	report := &DuplicateReport{SchemaVersion: currentSchemaVersions["duplicates"], MaxSwaps: *maxSwapsFlag, Tournaments: []string{}}
+	var decks []DeckInfo
+	var deckTournaments []string
+	for _, id := range tournamentIDs {
+		decklists, err := loadDecklists(id)
+		if err != nil {
+			if *allFlag {
+				log.Printf("  Skipping tournament %s: %v", id, err)
+				continue
+			}
+			return err
+		}
+		hashDecklists(decklists)
+		report.Tournaments = append(report.Tournaments, id)
+		for _, deck := range decklists {
+			if len(deck.MainDeck) == 0 {
+				continue
+			}
+			decks = append(decks, deck)
+			if *allFlag {
+				deckTournaments = append(deckTournaments, id)
+			} else {
+				deckTournaments = append(deckTournaments, "")
+			}
+		}
+	}
+
+	report.Decks = len(decks)
+	report.Identical = identicalDecks(decks, deckTournaments)
+	report.NearIdentical = nearIdenticalDecks(decks, deckTournaments, *maxSwapsFlag)
+
+	for _, g := range report.Identical {
+		log.Printf("  %d players registered the exact same %s list", len(g.Decks), describeArchetypes(g.Decks))
+	}
+	log.Printf("%d decks: %d groups of identical 75s, %d groups within %d swaps", report.Decks, len(report.Identical), len(report.NearIdentical), *maxSwapsFlag)
+
+	if *allFlag {
+		return writeJSON(filepath.Join(outputDir, duplicatesFile), report)
+	}
+	return saveJSON(*tournamentFlag, "duplicates", report)
+}
+
+// describeArchetypes joins the distinct archetype labels in a group
+func describeArchetypes(decks []DeckRef) string {
+	seen := make(map[string]bool)
+	var labels []string
+	for _, deck := range decks {
+		if !seen[deck.Archetype] {
+			seen[deck.Archetype] = true
+			labels = append(labels, deck.Archetype)
+		}
+	}
+	sort.Strings(labels)
+	return strings.Join(labels, "/")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCardListHash(t *testing.T) {
+	a := []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 2, Name: "Fire // Ice"}}
+	b := []CardInfo{{Quantity: 1, Name: "fire/ice"}, {Quantity: 4, Name: "Opt"}, {Quantity: 1, Name: "Fire // Ice"}}
+
+	if cardListHash(a) != cardListHash(b) {
+		t.Error("order, case, face separators and split entries should not change the hash")
+	}
+	if len(cardListHash(a)) != 16 {
+		t.Errorf("expected 16 hex digits, got %q", cardListHash(a))
+	}
+	if cardListHash(a) == cardListHash([]CardInfo{{Quantity: 3, Name: "Opt"}, {Quantity: 2, Name: "Fire // Ice"}}) {
+		t.Error("different counts should change the hash")
+	}
+}
+
+func TestDecklistsByID(t *testing.T) {
+	manaValue := 1.0
+	decklists := []DeckInfo{
+		{PlayerName: "Alice", DecklistID: "a", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt", OracleID: "stale", ManaValue: &manaValue}},
+			Summary: &DeckSummary{}, Violations: []DeckViolation{{Rule: "stale"}}},
+		{PlayerName: "Bob", DecklistID: "b", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}}},
+		{PlayerName: "Carol", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}}},
+		{PlayerName: "Failed Fetch", DecklistID: "d", MainDeck: []CardInfo{}},
+	}
+	hashDecklists(decklists)
+	decklists[1].MainDeck = []CardInfo{{Quantity: 3, Name: "Opt"}}
+
+	cached := decklistsByID(decklists)
+	if len(cached) != 1 {
+		t.Fatalf("only intact decks with a decklist ID should be cached: %v", cached)
+	}
+	want := DeckInfo{DecklistID: "a", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}}, Sideboard: []CardInfo{}}
+	if !reflect.DeepEqual(cached["a"], want) {
+		t.Errorf("cached decks should keep only card names and quantities, got %+v", cached["a"])
+	}
+}
+
+func TestIdenticalAndNearIdenticalDecks(t *testing.T) {
+	base := []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Shock"}}
+	decks := []DeckInfo{
+		{PlayerName: "Alice", Archetype: "Izzet", MainDeck: base, Sideboard: []CardInfo{{Quantity: 2, Name: "Negate"}}},
+		{PlayerName: "Bob", Archetype: "Izzet", MainDeck: base, Sideboard: []CardInfo{{Quantity: 2, Name: "Negate"}}},
+		// one Negate moved to the main deck
+		{PlayerName: "Carol", Archetype: "Izzet", MainDeck: append([]CardInfo{{Quantity: 1, Name: "Negate"}}, base...), Sideboard: []CardInfo{{Quantity: 1, Name: "Negate"}}},
+		// same main deck, different sideboard
+		{PlayerName: "Dave", Archetype: "Izzet", MainDeck: base, Sideboard: []CardInfo{{Quantity: 2, Name: "Spell Pierce"}}},
+		{PlayerName: "Erin", Archetype: "Mono-G", MainDeck: []CardInfo{{Quantity: 4, Name: "Llanowar Elves"}}},
+		{PlayerName: "No List", Archetype: "Izzet"},
+	}
+	hashDecklists(decks)
+	ids := []string{"A", "B", "A", "A", "A", "A"}
+
+	identical := identicalDecks(decks, ids)
+	want := []DuplicateGroup{{
+		Hash:  deckHash(decks[0]),
+		Decks: []DeckRef{{TournamentID: "A", PlayerName: "Alice", Archetype: "Izzet"}, {TournamentID: "B", PlayerName: "Bob", Archetype: "Izzet"}},
+	}}
+	if !reflect.DeepEqual(identical, want) {
+		t.Errorf("expected %+v, got %+v", want, identical)
+	}
+
+	near := nearIdenticalDecks(decks, ids, 1)
+	if len(near) != 1 || len(near[0].Decks) != 3 {
+		t.Fatalf("expected Alice, Bob and Carol within 1 swap: %+v", near)
+	}
+	if near := nearIdenticalDecks(decks, ids, 2); len(near) != 1 || len(near[0].Decks) != 4 {
+		t.Errorf("expected Dave to join within 2 swaps: %+v", near)
+	}
+}
+
+func TestCardSwaps(t *testing.T) {
+	a := map[string]float64{"main:Opt": 4, "side:Negate": 2}
+	tests := []struct {
+		b    map[string]float64
+		want int
+	}{
+		{map[string]float64{"main:Opt": 4, "side:Negate": 2}, 0},
+		{map[string]float64{"main:Opt": 3, "main:Shock": 1, "side:Negate": 2}, 1},
+		{map[string]float64{"main:Opt": 4, "side:Negate": 2, "main:Shock": 1}, 1},
+		{map[string]float64{"main:Opt": 2, "main:Shock": 2, "side:Spell Pierce": 2}, 4},
+	}
+	for _, tt := range tests {
+		if got := cardSwaps(a, tt.b); got != tt.want {
+			t.Errorf("cardSwaps(%v): expected %d, got %d", tt.b, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// "4 Lightning Bolt", "4x Lightning Bolt", "4 Lightning Bolt (M10) 146"
+	cardLinePattern = regexp.MustCompile(`^(\d+)x?\s+(.+?)(?:\s+\([A-Za-z0-9]{2,6}\)(?:\s+\S+)?)?$`)
+	// Section headers used by Arena and MTGO text exports, with or without a trailing colon
+	sectionHeaderPattern = regexp.MustCompile(`(?i)^(deck|main|maindeck|main deck|sideboard|commander|companion|about)\s*:?$`)
+)
+
+// parseCardLine parses a "4 Card Name" line, ignoring Arena set code and collector number
+func parseCardLine(line string) (CardInfo, bool) {
+	m := cardLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return CardInfo{}, false
+	}
+	quantity, err := strconv.Atoi(m[1])
+	if err != nil || quantity <= 0 {
+		return CardInfo{}, false
+	}
+	return CardInfo{Quantity: quantity, Name: normalizeSplitCardName(m[2])}, true
+}
+
+// normalizeSplitCardName rewrites MTGO's "Fire/Ice" and other spacings of two-part
+// names to the "Fire // Ice" form the scraped data uses
+func normalizeSplitCardName(name string) string {
+	name = strings.TrimSpace(name)
+	separator := "//"
+	if !strings.Contains(name, separator) {
+		separator = "/"
+	}
+	parts := strings.Split(name, separator)
+	if len(parts) < 2 {
+		return name
+	}
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+		if parts[i] == "" {
+			return name
+		}
+	}
+	return strings.Join(parts, " // ")
+}
+
+// parseDeckText parses Arena or MTGO text exports. Cards go to the main deck until a
+// "Sideboard" header or the first blank line after cards; a later "Deck" header
+// switches back to the main deck.
+func parseDeckText(text string) (mainDeck, sideboard []CardInfo) {
+	mainDeck, sideboard = []CardInfo{}, []CardInfo{}
+	inSideboard := false
+	inAbout := false
+	sawCards := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			inAbout = false
+			if sawCards {
+				inSideboard = true
+			}
+			continue
+		}
+
+		if m := sectionHeaderPattern.FindStringSubmatch(line); m != nil {
+			section := strings.ToLower(m[1])
+			inAbout = section == "about"
+			// Commander and companion cards are part of the 75 in Arena exports
+			inSideboard = section == "sideboard" || section == "companion"
+			continue
+		}
+		if inAbout {
+			continue
+		}
+
+		card, ok := parseCardLine(line)
+		if !ok {
+			continue
+		}
+		sawCards = true
+		if inSideboard {
+			sideboard = append(sideboard, card)
+		} else {
+			mainDeck = append(mainDeck, card)
+		}
+	}
+
+	return mainDeck, sideboard
+}
+
+// dekDocument is the subset of an MTGO .dek file we read
+type dekDocument struct {
+	Cards []struct {
+		Quantity  int    `xml:"Quantity,attr"`
+		Sideboard bool   `xml:"Sideboard,attr"`
+		Name      string `xml:"Name,attr"`
+	} `xml:"Cards"`
+}
+
+// parseDekXML parses an MTGO .dek file
+func parseDekXML(data []byte) (mainDeck, sideboard []CardInfo, err error) {
+	var doc dekDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parse .dek: %w", err)
+	}
+
+	mainDeck, sideboard = []CardInfo{}, []CardInfo{}
+	for _, c := range doc.Cards {
+		card := CardInfo{Quantity: c.Quantity, Name: normalizeSplitCardName(c.Name)}
+		if c.Sideboard {
+			sideboard = append(sideboard, card)
+		} else {
+			mainDeck = append(mainDeck, card)
+		}
+	}
+	return mainDeck, sideboard, nil
+}
+
+// csvColumns maps accepted CSV header names (lowercase) to the field they fill
+var csvColumns = map[string]string{
+	"quantity":  "quantity",
+	"qty":       "quantity",
+	"count":     "quantity",
+	"name":      "name",
+	"card":      "name",
+	"card name": "name",
+	"section":   "section",
+	"board":     "section",
+	"sideboard": "section",
+	"player":    "player",
+	"archetype": "archetype",
+	"deck":      "archetype",
+}
+
+// parseDeckCSV parses a CSV with a header row. quantity and name columns are required.
+// An optional section column marks sideboard rows ("sideboard", "side", "sb", "true").
+// Optional player and archetype columns allow many decks in one file; rows without a
+// player belong to defaultPlayer.
+func parseDeckCSV(data []byte, defaultPlayer, defaultArchetype string) ([]DeckInfo, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, h := range header {
+		if field, ok := csvColumns[strings.ToLower(strings.TrimSpace(h))]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns["quantity"]; !ok {
+		return nil, fmt.Errorf("csv has no quantity column (header: %v)", header)
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("csv has no name column (header: %v)", header)
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var decks []DeckInfo
+	index := make(map[string]int)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv line %d: %w", line, err)
+		}
+
+		quantity, err := strconv.Atoi(field(record, "quantity"))
+		if err != nil || quantity <= 0 {
+			return nil, fmt.Errorf("csv line %d: invalid quantity %q", line, field(record, "quantity"))
+		}
+		name := field(record, "name")
+		if name == "" {
+			return nil, fmt.Errorf("csv line %d: empty card name", line)
+		}
+
+		player := field(record, "player")
+		if player == "" {
+			player = defaultPlayer
+		}
+		i, exists := index[player]
+		if !exists {
+			i = len(decks)
+			index[player] = i
+			decks = append(decks, DeckInfo{PlayerName: player, Archetype: defaultArchetype, MainDeck: []CardInfo{}, Sideboard: []CardInfo{}})
+		}
+		if archetype := field(record, "archetype"); archetype != "" {
+			decks[i].Archetype = archetype
+		}
+
+		card := CardInfo{Quantity: quantity, Name: normalizeSplitCardName(name)}
+		switch strings.ToLower(field(record, "section")) {
+		case "sideboard", "side", "sb", "true", "1":
+			decks[i].Sideboard = append(decks[i].Sideboard, card)
+		default:
+			decks[i].MainDeck = append(decks[i].MainDeck, card)
+		}
+	}
+
+	for _, deck := range decks {
+		if len(deck.MainDeck) == 0 {
+			return nil, fmt.Errorf("csv deck for %s has no main deck cards", deck.PlayerName)
+		}
+	}
+	return decks, nil
+}
+
+// detectDeckFormat picks an importer from the file extension, falling back to content
+func detectDeckFormat(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".dek":
+		return "dek"
+	case ".csv":
+		return "csv"
+	}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<Deck")) {
+		return "dek"
+	}
+	return "text"
+}
+
+// importDeckFile reads one decklist file. player and archetype label single-deck
+// formats and are defaults for CSV files.
+func importDeckFile(path, format, player, archetype string) ([]DeckInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if format == "" || format == "auto" {
+		format = detectDeckFormat(path, data)
+	}
+	if player == "" {
+		player = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	var mainDeck, sideboard []CardInfo
+	switch format {
+	case "text":
+		mainDeck, sideboard = parseDeckText(string(data))
+	case "dek":
+		mainDeck, sideboard, err = parseDekXML(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case "csv":
+		decks, err := parseDeckCSV(data, player, archetype)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return decks, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q (want auto, text, dek or csv)", format)
+	}
+
+	if len(mainDeck) == 0 {
+		return nil, fmt.Errorf("%s: no cards found", path)
+	}
+	return []DeckInfo{{PlayerName: player, Archetype: archetype, MainDeck: mainDeck, Sideboard: sideboard}}, nil
+}
+
+// mergeDecklists adds imported decks to existing ones, replacing any deck of the
+// same (normalized) player
+func mergeDecklists(existing, imported []DeckInfo) []DeckInfo {
+	index := make(map[string]int)
+	for i, deck := range existing {
+		index[normalizePlayerName(deck.PlayerName)] = i
+	}
+	for _, deck := range imported {
+		key := normalizePlayerName(deck.PlayerName)
+		if i, ok := index[key]; ok {
+			existing[i] = deck
+			continue
+		}
+		index[key] = len(existing)
+		existing = append(existing, deck)
+	}
+	return existing
+}
+
+// runImportDecks implements the import-decks command
+func runImportDecks(args []string) error {
+	fs := flag.NewFlagSet("import-decks", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Tournament ID whose decklists file receives the decks (required; need not be a melee.gg ID)")
+	playerFlag := fs.String("player", "", "Player name for single-deck files. Defaults to the file name.")
+	archetypeFlag := fs.String("archetype", "", "Archetype label for imported decks")
+	formatFlag := fs.String("format", "auto", "Input format: auto, text (Arena/MTGO), dek or csv")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: scraper import-decks -tournament ID [flags] FILE...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *tournamentFlag == "" {
+		return fmt.Errorf("-tournament is required")
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no decklist files given")
+	}
+	if *playerFlag != "" && fs.NArg() > 1 {
+		return fmt.Errorf("-player can only be used with a single file")
+	}
+
+	var imported []DeckInfo
+	for _, path := range fs.Args() {
+		decks, err := importDeckFile(path, *formatFlag, *playerFlag, *archetypeFlag)
+		if err != nil {
+			return err
+		}
+		for _, deck := range decks {
+			log.Printf("  %s: %s (%s), %d main / %d sideboard entries", path, deck.PlayerName, deck.Archetype, len(deck.MainDeck), len(deck.Sideboard))
+		}
+		imported = append(imported, decks...)
+	}
+
+	existing, err := loadDecklists(*tournamentFlag)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	decklists := mergeDecklists(existing, imported)
+	hashDecklists(decklists)
+	log.Printf("Imported %d decks, tournament %s now has %d decklists", len(imported), *tournamentFlag, len(decklists))
+	if err := saveDecklistsData(*tournamentFlag, decklists); err != nil {
+		return err
+	}
+
+	// The rewritten decklists file is at the current schema; record that so
+	// migrate doesn't treat it as an older version
+	manifest, err := loadOutputManifest(*tournamentFlag)
+	if err != nil {
+		return err
+	}
+	if err := manifest.record("decklists", len(decklists)); err != nil {
+		return err
+	}
+	return saveOutputManifest(*tournamentFlag, manifest)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCardLine(t *testing.T) {
+	tests := map[string]CardInfo{
+		"4 Lightning Bolt":                   {Quantity: 4, Name: "Lightning Bolt"},
+		"  2x Opt  ":                         {Quantity: 2, Name: "Opt"},
+		"1 Lightning Bolt (M10) 146":         {Quantity: 1, Name: "Lightning Bolt"},
+		"3 Fire // Ice (MH2) 290":            {Quantity: 3, Name: "Fire // Ice"},
+		"1 Hearth Elemental // Stoke Genius": {Quantity: 1, Name: "Hearth Elemental // Stoke Genius"},
+	}
+	for line, want := range tests {
+		got, ok := parseCardLine(line)
+		if !ok || got != want {
+			t.Errorf("parseCardLine(%q): expected %+v, got %+v ok=%v", line, want, got, ok)
+		}
+	}
+
+	for _, line := range []string{"Sideboard", "0 Opt", "Name PT - Izzet"} {
+		if _, ok := parseCardLine(line); ok {
+			t.Errorf("parseCardLine(%q) should fail", line)
+		}
+	}
+}
+
+func TestParseDeckText(t *testing.T) {
+	arena := formatDeckForArena(testExportDeck(), nil)
+	mainDeck, sideboard := parseDeckText(arena)
+
+	wantMain := []CardInfo{{Quantity: 4, Name: "Accumulate Wisdom"}, {Quantity: 2, Name: "Hearth Elemental"}}
+	wantSide := []CardInfo{{Quantity: 2, Name: "Soul-Guide Lantern"}}
+	if !reflect.DeepEqual(mainDeck, wantMain) || !reflect.DeepEqual(sideboard, wantSide) {
+		t.Errorf("arena round trip: main=%+v side=%+v", mainDeck, sideboard)
+	}
+
+	mtgo := "4 Opt\r\n20 Island\r\n\r\n3 Negate\r\n"
+	mainDeck, sideboard = parseDeckText(mtgo)
+	if len(mainDeck) != 2 || len(sideboard) != 1 || sideboard[0].Name != "Negate" {
+		t.Errorf("MTGO blank-line sideboard: main=%+v side=%+v", mainDeck, sideboard)
+	}
+
+	headers := "Main Deck:\n4 Opt\nSIDEBOARD:\n3 Negate\n"
+	mainDeck, sideboard = parseDeckText(headers)
+	if len(mainDeck) != 1 || len(sideboard) != 1 {
+		t.Errorf("header sideboard: main=%+v side=%+v", mainDeck, sideboard)
+	}
+}
+
+func TestParseDekXML(t *testing.T) {
+	data, err := formatDeckForMTGO(testExportDeck(), nil)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	mainDeck, sideboard, err := parseDekXML(data)
+	if err != nil {
+		t.Fatalf("parseDekXML error: %v", err)
+	}
+	if len(mainDeck) != 2 || mainDeck[0] != (CardInfo{Quantity: 4, Name: "Accumulate Wisdom"}) {
+		t.Errorf("main deck wrong: %+v", mainDeck)
+	}
+	if len(sideboard) != 1 || sideboard[0].Name != "Soul-Guide Lantern" {
+		t.Errorf("sideboard wrong: %+v", sideboard)
+	}
+
+	if _, _, err := parseDekXML([]byte("<Deck><Cards")); err == nil {
+		t.Error("expected error for malformed XML")
+	}
+}
+
+func TestParseDeckCSV(t *testing.T) {
+	data := []byte("Player,Archetype,Qty,Card Name,Board\n" +
+		"Alice,Izzet Prowess,4,Opt,main\n" +
+		"Alice,,2,Negate,sideboard\n" +
+		"Bob,Mono-Green Landfall,20,Forest,\n" +
+		",,4,Llanowar Elves,\n")
+
+	decks, err := parseDeckCSV(data, "Team Testing", "Unknown")
+	if err != nil {
+		t.Fatalf("parseDeckCSV error: %v", err)
+	}
+	if len(decks) != 3 {
+		t.Fatalf("expected 3 decks, got %+v", decks)
+	}
+	if decks[0].PlayerName != "Alice" || decks[0].Archetype != "Izzet Prowess" || len(decks[0].MainDeck) != 1 || len(decks[0].Sideboard) != 1 {
+		t.Errorf("Alice's deck wrong: %+v", decks[0])
+	}
+	if decks[2].PlayerName != "Team Testing" || decks[2].Archetype != "Unknown" {
+		t.Errorf("rows without a player should use the defaults: %+v", decks[2])
+	}
+
+	if _, err := parseDeckCSV([]byte("name\nOpt\n"), "", ""); err == nil {
+		t.Error("expected error for missing quantity column")
+	}
+	if _, err := parseDeckCSV([]byte("qty,name\nfour,Opt\n"), "", ""); err == nil {
+		t.Error("expected error for non-numeric quantity")
+	}
+	if _, err := parseDeckCSV([]byte("player,qty,name,board\nAlice,4,Opt,main\nBob,3,Negate,sideboard\n"), "", ""); err == nil {
+		t.Error("expected error for a deck with only sideboard cards")
+	}
+}
+
+func TestNormalizeSplitCardName(t *testing.T) {
+	tests := map[string]string{
+		"Fire/Ice":                         "Fire // Ice",
+		"Roaring Furnace/Steaming Sauna":   "Roaring Furnace // Steaming Sauna",
+		"Fire//Ice":                        "Fire // Ice",
+		"Hearth Elemental // Stoke Genius": "Hearth Elemental // Stoke Genius",
+		" Opt ":                            "Opt",
+		"/":                                "/",
+	}
+	for in, want := range tests {
+		if got := normalizeSplitCardName(in); got != want {
+			t.Errorf("normalizeSplitCardName(%q): expected %q, got %q", in, want, got)
+		}
+	}
+
+	mainDeck, _, err := parseDekXML([]byte(`<Deck><Cards Quantity="1" Sideboard="false" Name="Unholy Annex/Ritual Chamber" /></Deck>`))
+	if err != nil || len(mainDeck) != 1 || mainDeck[0].Name != "Unholy Annex // Ritual Chamber" {
+		t.Errorf("expected the .dek split name normalized, got %+v (%v)", mainDeck, err)
+	}
+}
+
+func TestMergeDecklists(t *testing.T) {
+	existing := []DeckInfo{{PlayerName: "Alice", Archetype: "Old"}, {PlayerName: "Bob", Archetype: "Bob's"}}
+	imported := []DeckInfo{{PlayerName: "alice ", Archetype: "New"}, {PlayerName: "Carol", Archetype: "Carol's"}}
+
+	merged := mergeDecklists(existing, imported)
+	if len(merged) != 3 || merged[0].Archetype != "New" || merged[2].PlayerName != "Carol" {
+		t.Errorf("merge wrong: %+v", merged)
+	}
+}
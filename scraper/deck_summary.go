@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/sp3c1/protour-data-viz/scraper/carddata"
+)
+
+// manaCurveBuckets is the length of DeckSummary.ManaCurve; the last bucket holds 7+
+const manaCurveBuckets = 8
+
+// DeckSummary holds main-deck properties derived from card metadata, so consumers
+// can filter by colors or land count without re-deriving them
+type DeckSummary struct {
+	Colors        string  `json:"colors"`
+	ColorIdentity string  `json:"colorIdentity"`
+	ManaCurve     []int   `json:"manaCurve"`
+	Lands         int     `json:"lands"`
+	Creatures     int     `json:"creatures"`
+	Spells        int     `json:"spells"`
+	AvgManaValue  float64 `json:"avgManaValue"`
+}
+
+// summarizeDeck derives a DeckSummary from the main deck. Cards are classified by
+// their front face, so a creature with a land back face counts as a creature.
+// The mana curve and average mana value cover nonland cards only.
+func summarizeDeck(deck DeckInfo, resolved map[string]*carddata.Card) *DeckSummary {
+	summary := &DeckSummary{ManaCurve: make([]int, manaCurveBuckets)}
+
+	var colors, identity []string
+	totalManaValue := 0.0
+	for _, card := range deck.MainDeck {
+		data := resolved[card.Name]
+		if data == nil {
+			continue
+		}
+		colors = append(colors, data.Colors...)
+		identity = append(identity, data.ColorIdentity...)
+
+		frontType := strings.SplitN(data.TypeLine, " // ", 2)[0]
+		switch {
+		case strings.Contains(frontType, "Land"):
+			summary.Lands += card.Quantity
+			continue
+		case strings.Contains(frontType, "Creature"):
+			summary.Creatures += card.Quantity
+		default:
+			summary.Spells += card.Quantity
+		}
+
+		bucket := int(data.ManaValue)
+		if bucket >= manaCurveBuckets {
+			bucket = manaCurveBuckets - 1
+		}
+		summary.ManaCurve[bucket] += card.Quantity
+		totalManaValue += data.ManaValue * float64(card.Quantity)
+	}
+
+	summary.Colors = normalizeColors(strings.Join(colors, ""))
+	summary.ColorIdentity = normalizeColors(strings.Join(identity, ""))
+	if nonland := summary.Creatures + summary.Spells; nonland > 0 {
+		summary.AvgManaValue = totalManaValue / float64(nonland)
+	}
+
+	return summary
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/sp3c1/protour-data-viz/scraper/carddata"
+)
+
+func TestSummarizeDeck(t *testing.T) {
+	resolved := map[string]*carddata.Card{
+		"Steam Vents":    {Name: "Steam Vents", TypeLine: "Land — Island Mountain", ColorIdentity: []string{"U", "R"}},
+		"Island":         {Name: "Island", TypeLine: "Basic Land — Island", ColorIdentity: []string{"U"}},
+		"Opt":            {Name: "Opt", ManaValue: 1, Colors: []string{"U"}, ColorIdentity: []string{"U"}, TypeLine: "Instant"},
+		"Slickshot":      {Name: "Slickshot", ManaValue: 2, Colors: []string{"R"}, ColorIdentity: []string{"R"}, TypeLine: "Creature — Lizard Warrior"},
+		"Big Thing":      {Name: "Big Thing", ManaValue: 9, TypeLine: "Artifact Creature — Golem"},
+		"Ojer // Temple": {Name: "Ojer // Temple", ManaValue: 3, Colors: []string{"R"}, ColorIdentity: []string{"R"}, TypeLine: "Legendary Creature — God // Land"},
+		"Sideboard Only": {Name: "Sideboard Only", ManaValue: 1, Colors: []string{"W"}, ColorIdentity: []string{"W"}, TypeLine: "Instant"},
+	}
+	deck := DeckInfo{
+		MainDeck: []CardInfo{
+			{Quantity: 4, Name: "Steam Vents"},
+			{Quantity: 16, Name: "Island"},
+			{Quantity: 4, Name: "Opt"},
+			{Quantity: 4, Name: "Slickshot"},
+			{Quantity: 1, Name: "Big Thing"},
+			{Quantity: 1, Name: "Ojer // Temple"},
+		},
+		Sideboard: []CardInfo{{Quantity: 2, Name: "Sideboard Only"}},
+	}
+
+	summary := summarizeDeck(deck, resolved)
+
+	if summary.Lands != 20 || summary.Creatures != 6 || summary.Spells != 4 {
+		t.Errorf("type counts wrong: %+v", summary)
+	}
+	if summary.Colors != "UR" || summary.ColorIdentity != "UR" {
+		t.Errorf("colors wrong: %q / %q", summary.Colors, summary.ColorIdentity)
+	}
+	if want := []int{0, 4, 4, 1, 0, 0, 0, 1}; !reflect.DeepEqual(summary.ManaCurve, want) {
+		t.Errorf("mana curve: expected %v, got %v", want, summary.ManaCurve)
+	}
+	if want := (4.0 + 8 + 9 + 3) / 10; math.Abs(summary.AvgManaValue-want) > 1e-9 {
+		t.Errorf("avg mana value: expected %v, got %v", want, summary.AvgManaValue)
+	}
+}
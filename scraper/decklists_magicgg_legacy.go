@@ -18,16 +18,29 @@ const (
 
 // DeckInfo represents a player's deck information
 type DeckInfo struct {
-	PlayerName string     `json:"playerName"`
-	Archetype  string     `json:"archetype"`
-	MainDeck   []CardInfo `json:"mainDeck"`
-	Sideboard  []CardInfo `json:"sideboard"`
+	PlayerName          string          `json:"playerName"`
+	Archetype           string          `json:"archetype"`
+	ClassifiedArchetype string          `json:"classifiedArchetype,omitempty"`
+	DecklistID          string          `json:"decklistId,omitempty"`
+	MainHash            string          `json:"mainHash,omitempty"`
+	SideboardHash       string          `json:"sideboardHash,omitempty"`
+	MainDeck            []CardInfo      `json:"mainDeck"`
+	Sideboard           []CardInfo      `json:"sideboard"`
+	Summary             *DeckSummary    `json:"summary,omitempty"`
+	Violations          []DeckViolation `json:"violations,omitempty"`
+	Similar             []SimilarDeck   `json:"similar,omitempty"`
 }
 
 // CardInfo represents a card with quantity
+// The metadata fields are filled in by enrichDecklists when a card data file is given.
 type CardInfo struct {
-	Quantity int    `json:"quantity"`
-	Name     string `json:"name"`
+	Quantity  int      `json:"quantity"`
+	Name      string   `json:"name"`
+	OracleID  string   `json:"oracleId,omitempty"`
+	ManaValue *float64 `json:"manaValue,omitempty"`
+	Colors    string   `json:"colors,omitempty"`
+	TypeLine  string   `json:"typeLine,omitempty"`
+	Rarity    string   `json:"rarity,omitempty"`
 }
 
 // fetchDecklists fetches all decklists from magic.gg pages
@@ -118,27 +131,11 @@ func extractCards(deckContent, section string) []CardInfo {
 	
 	sectionContent := sectionMatch[1]
 	
-	// Split by newlines and parse each card line
+	// Split by newlines and parse each card line ("4 Card Name")
 	lines := strings.Split(sectionContent, "\n")
 	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		
-		// Pattern: "4 Card Name" or "1 Card Name"
-		cardPattern := regexp.MustCompile(`^(\d+)\s+(.+)$`)
-		cardMatch := cardPattern.FindStringSubmatch(line)
-		
-		if len(cardMatch) >= 3 {
-			quantity := 0
-			fmt.Sscanf(cardMatch[1], "%d", &quantity)
-			cardName := strings.TrimSpace(cardMatch[2])
-			
-			cards = append(cards, CardInfo{
-				Quantity: quantity,
-				Name:     cardName,
-			})
+		if card, ok := parseCardLine(line); ok {
+			cards = append(cards, card)
 		}
 	}
 	
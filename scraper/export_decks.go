@@ -0,0 +1,389 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sp3c1/protour-data-viz/scraper/carddata"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// deckExportFormat renders one deck as a file of a given format
+type deckExportFormat struct {
+	extension string
+	render    func(deck DeckInfo, layouts cardLayouts) ([]byte, error)
+}
+
+var deckExportFormats = map[string]deckExportFormat{
+	"arena": {".txt", func(deck DeckInfo, layouts cardLayouts) ([]byte, error) {
+		return []byte(formatDeckForArena(deck, layouts)), nil
+	}},
+	"mtgo":       {".dek", formatDeckForMTGO},
+	"cockatrice": {".cod", formatDeckForCockatrice},
+}
+
+// cardLayouts maps decklist card names to their card data layout ("split",
+// "adventure", "transform", ...). Names missing from it are treated as one-faced.
+type cardLayouts map[string]string
+
+// fullNameLayouts are the two-part layouts whose deck files name both halves:
+// split cards, including rooms, and aftermath cards. Adventure, flip and
+// double-faced cards go by their front face.
+var fullNameLayouts = map[string]bool{
+	"split":     true,
+	"aftermath": true,
+}
+
+// runExportDecks implements the export-decks command
+func runExportDecks(args []string) error {
+	fs := flag.NewFlagSet("export-decks", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Tournament ID whose decklists to export (required)")
+	playerFlag := fs.String("player", "", "Export this player's deck as loose files")
+	archetypeFlag := fs.String("archetype", "", "Export every deck of this archetype as one zip")
+	formatFlag := fs.String("format", "all", "Export format: arena, mtgo, cockatrice or all")
+	outputFlag := fs.String("output", "exports", "Output directory")
+	cardDataFlag := fs.String("carddata", "", "Local Scryfall or MTGJSON card file, used to tell split and room cards (full name) from adventure and double-faced cards (front face)")
+	fs.Parse(args)
+
+	if *tournamentFlag == "" {
+		return fmt.Errorf("-tournament is required")
+	}
+	if *playerFlag != "" && *archetypeFlag != "" {
+		return fmt.Errorf("-player and -archetype are mutually exclusive")
+	}
+
+	formats, err := selectExportFormats(*formatFlag)
+	if err != nil {
+		return err
+	}
+
+	decklists, err := loadDecklists(*tournamentFlag)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*outputFlag, 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+
+	var layouts cardLayouts
+	if *cardDataFlag != "" {
+		db, err := carddata.Load(*cardDataFlag)
+		if err != nil {
+			return err
+		}
+		layouts = decklistCardLayouts(decklists, db)
+	} else if names := twoPartCardNames(decklists); len(names) > 0 {
+		log.Printf("Warning: no -carddata, so %d two-part cards are exported by their front face, including any split or room cards", len(names))
+	}
+
+	if *playerFlag != "" {
+		wanted := normalizePlayerName(*playerFlag)
+		var decks []DeckInfo
+		for _, deck := range decklists {
+			if normalizePlayerName(deck.PlayerName) == wanted {
+				decks = append(decks, deck)
+			}
+		}
+		if len(decks) > 0 {
+			return exportDeckFiles(*outputFlag, decks, formats, layouts)
+		}
+		return fmt.Errorf("player %q not found in tournament %s", *playerFlag, *tournamentFlag)
+	}
+
+	byArchetype := make(map[string][]DeckInfo)
+	for _, deck := range decklists {
+		if len(deck.MainDeck) == 0 {
+			continue
+		}
+		if *archetypeFlag != "" && !strings.EqualFold(deck.Archetype, *archetypeFlag) {
+			continue
+		}
+		byArchetype[deck.Archetype] = append(byArchetype[deck.Archetype], deck)
+	}
+	if len(byArchetype) == 0 {
+		return fmt.Errorf("no decks to export")
+	}
+
+	archetypes := make([]string, 0, len(byArchetype))
+	for archetype := range byArchetype {
+		archetypes = append(archetypes, archetype)
+	}
+	sort.Strings(archetypes)
+
+	for _, archetype := range archetypes {
+		zipPath := filepath.Join(*outputFlag, fmt.Sprintf("%s-%s.zip", *tournamentFlag, fileSlug(archetype)))
+		if err := exportDeckZip(zipPath, byArchetype[archetype], formats, layouts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectExportFormats resolves the -format flag to a sorted list of format names
+func selectExportFormats(value string) ([]string, error) {
+	if value == "all" {
+		names := make([]string, 0, len(deckExportFormats))
+		for name := range deckExportFormats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+	if _, ok := deckExportFormats[value]; !ok {
+		return nil, fmt.Errorf("unknown format %q (want arena, mtgo, cockatrice or all)", value)
+	}
+	return []string{value}, nil
+}
+
+// decklistCardLayouts looks up the layout of every two-part card name in the decklists
+func decklistCardLayouts(decklists []DeckInfo, db *carddata.DB) cardLayouts {
+	layouts := make(cardLayouts)
+	for _, name := range twoPartCardNames(decklists) {
+		if card, ok := db.Resolve(name); ok {
+			layouts[name] = card.Layout
+		} else {
+			log.Printf("Warning: %q not found in card data, exporting its front face", name)
+		}
+	}
+	return layouts
+}
+
+// twoPartCardNames returns the decklists' "A // B" card names
+func twoPartCardNames(decklists []DeckInfo) []string {
+	var names []string
+	for _, name := range decklistCardNames(decklists) {
+		if strings.Contains(name, "//") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// exportDeckFiles writes one file per format for each deck, normally a single
+// player's (see deckFileNames for players sharing a name)
+func exportDeckFiles(dir string, decks []DeckInfo, formats []string, layouts cardLayouts) error {
+	fileNames := deckFileNames(decks)
+	for i, deck := range decks {
+		for _, name := range formats {
+			format := deckExportFormats[name]
+			data, err := format.render(deck, layouts)
+			if err != nil {
+				return fmt.Errorf("render %s for %s: %w", name, deck.PlayerName, err)
+			}
+			path := filepath.Join(dir, fileNames[i]+format.extension)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				return fmt.Errorf("write %s: %w", path, err)
+			}
+			log.Printf("    Saved %s", path)
+		}
+	}
+	return nil
+}
+
+// exportDeckZip writes every deck, in every format, into one zip
+func exportDeckZip(path string, decks []DeckInfo, formats []string, layouts cardLayouts) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	fileNames := deckFileNames(decks)
+	for i, deck := range decks {
+		for _, name := range formats {
+			format := deckExportFormats[name]
+			data, err := format.render(deck, layouts)
+			if err != nil {
+				return fmt.Errorf("render %s for %s: %w", name, deck.PlayerName, err)
+			}
+			entry, err := archive.Create(fileNames[i] + format.extension)
+			if err != nil {
+				return fmt.Errorf("add to %s: %w", path, err)
+			}
+			if _, err := entry.Write(data); err != nil {
+				return fmt.Errorf("write to %s: %w", path, err)
+			}
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("finish %s: %w", path, err)
+	}
+
+	log.Printf("    Saved %s (%d decks)", path, len(decks))
+	return nil
+}
+
+var (
+	nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+	slugFolder   = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)))
+)
+
+// fileSlug turns a player or archetype name into a safe file name
+func fileSlug(name string) string {
+	if folded, _, err := transform.String(slugFolder, name); err == nil {
+		name = folded
+	}
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		return "deck"
+	}
+	return slug
+}
+
+// deckFileNames returns a file name (without extension) per deck: the player's
+// slug, followed by the decklist ID (or a counter) when two players share a slug
+func deckFileNames(decks []DeckInfo) []string {
+	counts := make(map[string]int)
+	for _, deck := range decks {
+		counts[fileSlug(deck.PlayerName)]++
+	}
+
+	names := make([]string, len(decks))
+	used := make(map[string]bool)
+	for i, deck := range decks {
+		name := fileSlug(deck.PlayerName)
+		if counts[name] > 1 {
+			suffix := fileSlug(deck.DecklistID)
+			if deck.DecklistID == "" {
+				suffix = "1"
+			}
+			base := name
+			name = base + "-" + suffix
+			for n := 2; used[name]; n++ {
+				name = fmt.Sprintf("%s-%d", base, n)
+			}
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// exportCardName returns a card's name in a deck file. Split and room cards keep
+// both halves, as "A // B" or, for MTGO, "A/B"; other two-part cards (adventure,
+// flip and double-faced) go by their front face.
+func exportCardName(name string, layouts cardLayouts, mtgo bool) string {
+	front, back, twoPart := strings.Cut(name, "//")
+	if !twoPart {
+		return name
+	}
+	front, back = strings.TrimSpace(front), strings.TrimSpace(back)
+	switch {
+	case !fullNameLayouts[layouts[name]]:
+		return front
+	case mtgo:
+		return front + "/" + back
+	default:
+		return front + " // " + back
+	}
+}
+
+// formatDeckForArena renders a deck as MTG Arena import text
+func formatDeckForArena(deck DeckInfo, layouts cardLayouts) string {
+	var lines []string
+
+	lines = append(lines, "About", "Name PT - "+deck.Archetype+" - "+deck.PlayerName, "")
+
+	lines = append(lines, "Deck")
+	for _, card := range deck.MainDeck {
+		lines = append(lines, fmt.Sprintf("%d %s", card.Quantity, exportCardName(card.Name, layouts, false)))
+	}
+
+	if len(deck.Sideboard) > 0 {
+		lines = append(lines, "", "Sideboard")
+		for _, card := range deck.Sideboard {
+			lines = append(lines, fmt.Sprintf("%d %s", card.Quantity, exportCardName(card.Name, layouts, false)))
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// mtgoDeck is the MTGO .dek XML document
+type mtgoDeck struct {
+	XMLName              xml.Name   `xml:"Deck"`
+	NetDeckID            int        `xml:"NetDeckID"`
+	PreconstructedDeckID int        `xml:"PreconstructedDeckID"`
+	Cards                []mtgoCard `xml:"Cards"`
+}
+
+type mtgoCard struct {
+	Quantity  int    `xml:"Quantity,attr"`
+	Sideboard bool   `xml:"Sideboard,attr"`
+	Name      string `xml:"Name,attr"`
+}
+
+// formatDeckForMTGO renders a deck as MTGO .dek XML. Catalog IDs are not known,
+// so cards are identified by name only.
+func formatDeckForMTGO(deck DeckInfo, layouts cardLayouts) ([]byte, error) {
+	doc := mtgoDeck{}
+	for _, card := range deck.MainDeck {
+		doc.Cards = append(doc.Cards, mtgoCard{Quantity: card.Quantity, Name: exportCardName(card.Name, layouts, true)})
+	}
+	for _, card := range deck.Sideboard {
+		doc.Cards = append(doc.Cards, mtgoCard{Quantity: card.Quantity, Sideboard: true, Name: exportCardName(card.Name, layouts, true)})
+	}
+	return marshalXMLDocument(doc)
+}
+
+// cockatriceDeck is the Cockatrice .cod XML document
+type cockatriceDeck struct {
+	XMLName  xml.Name         `xml:"cockatrice_deck"`
+	Version  int              `xml:"version,attr"`
+	DeckName string           `xml:"deckname"`
+	Comments string           `xml:"comments"`
+	Zones    []cockatriceZone `xml:"zone"`
+}
+
+type cockatriceZone struct {
+	Name  string           `xml:"name,attr"`
+	Cards []cockatriceCard `xml:"card"`
+}
+
+type cockatriceCard struct {
+	Number int    `xml:"number,attr"`
+	Name   string `xml:"name,attr"`
+}
+
+// formatDeckForCockatrice renders a deck as a Cockatrice .cod file
+func formatDeckForCockatrice(deck DeckInfo, layouts cardLayouts) ([]byte, error) {
+	zone := func(name string, cards []CardInfo) cockatriceZone {
+		z := cockatriceZone{Name: name}
+		for _, card := range cards {
+			z.Cards = append(z.Cards, cockatriceCard{Number: card.Quantity, Name: exportCardName(card.Name, layouts, false)})
+		}
+		return z
+	}
+
+	doc := cockatriceDeck{
+		Version:  1,
+		DeckName: deck.Archetype + " - " + deck.PlayerName,
+		Comments: "PT - " + deck.Archetype + " - " + deck.PlayerName,
+		Zones:    []cockatriceZone{zone("main", deck.MainDeck)},
+	}
+	if len(deck.Sideboard) > 0 {
+		doc.Zones = append(doc.Zones, zone("side", deck.Sideboard))
+	}
+	return marshalXMLDocument(doc)
+}
+
+// marshalXMLDocument indents v and prefixes the XML declaration
+func marshalXMLDocument(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
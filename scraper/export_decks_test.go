@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func testExportDeck() DeckInfo {
+	return DeckInfo{
+		PlayerName: "David Åberg",
+		Archetype:  "Izzet Lessons",
+		MainDeck: []CardInfo{
+			{Quantity: 4, Name: "Accumulate Wisdom"},
+			{Quantity: 2, Name: "Hearth Elemental // Stoke Genius"},
+		},
+		Sideboard: []CardInfo{{Quantity: 2, Name: "Soul-Guide Lantern"}},
+	}
+}
+
+func TestFormatDeckForArena(t *testing.T) {
+	want := "About\nName PT - Izzet Lessons - David Åberg\n\nDeck\n4 Accumulate Wisdom\n2 Hearth Elemental\n\nSideboard\n2 Soul-Guide Lantern\n"
+	if got := formatDeckForArena(testExportDeck(), nil); got != want {
+		t.Errorf("arena export mismatch:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatDeckForMTGO(t *testing.T) {
+	data, err := formatDeckForMTGO(testExportDeck(), nil)
+	if err != nil {
+		t.Fatalf("formatDeckForMTGO error: %v", err)
+	}
+	out := string(data)
+
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<Cards Quantity="4" Sideboard="false" Name="Accumulate Wisdom"></Cards>`,
+		`<Cards Quantity="2" Sideboard="false" Name="Hearth Elemental"></Cards>`,
+		`<Cards Quantity="2" Sideboard="true" Name="Soul-Guide Lantern"></Cards>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("MTGO export missing %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatDeckForCockatrice(t *testing.T) {
+	data, err := formatDeckForCockatrice(testExportDeck(), nil)
+	if err != nil {
+		t.Fatalf("formatDeckForCockatrice error: %v", err)
+	}
+	out := string(data)
+
+	for _, want := range []string{
+		`<cockatrice_deck version="1">`,
+		`<deckname>Izzet Lessons - David Åberg</deckname>`,
+		`<zone name="main">`,
+		`<card number="2" name="Hearth Elemental"></card>`,
+		`<zone name="side">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Cockatrice export missing %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestFileSlug(t *testing.T) {
+	tests := map[string]string{
+		"David Åberg":   "david-aberg",
+		"Izzet Prowess": "izzet-prowess",
+		"???":           "deck",
+	}
+	for in, want := range tests {
+		if got := fileSlug(in); got != want {
+			t.Errorf("fileSlug(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestExportCardName_Layouts(t *testing.T) {
+	layouts := cardLayouts{
+		"Roaring Furnace // Steaming Sauna":             "split",
+		"Hearth Elemental // Stoke Genius":              "adventure",
+		"Ojer Axonil, Deepest Might // Temple of Power": "transform",
+	}
+	deck := DeckInfo{
+		PlayerName: "Alice",
+		Archetype:  "Izzet Lessons",
+		MainDeck: []CardInfo{
+			{Quantity: 1, Name: "Roaring Furnace // Steaming Sauna"},
+			{Quantity: 2, Name: "Hearth Elemental // Stoke Genius"},
+			{Quantity: 1, Name: "Ojer Axonil, Deepest Might // Temple of Power"},
+		},
+	}
+
+	arena := formatDeckForArena(deck, layouts)
+	for _, want := range []string{"1 Roaring Furnace // Steaming Sauna\n", "2 Hearth Elemental\n", "1 Ojer Axonil, Deepest Might\n"} {
+		if !strings.Contains(arena, want) {
+			t.Errorf("arena export missing %q:\n%s", want, arena)
+		}
+	}
+
+	mtgo, err := formatDeckForMTGO(deck, layouts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(mtgo), `Name="Roaring Furnace/Steaming Sauna"`) {
+		t.Errorf("MTGO export should use the A/B split name:\n%s", mtgo)
+	}
+
+	cockatrice, err := formatDeckForCockatrice(deck, layouts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(cockatrice), `name="Roaring Furnace // Steaming Sauna"`) {
+		t.Errorf("Cockatrice export should keep the full split name:\n%s", cockatrice)
+	}
+}
+
+func TestDeckFileNames_Collisions(t *testing.T) {
+	decks := []DeckInfo{
+		{PlayerName: "Jane Doe", DecklistID: "abc-123"},
+		{PlayerName: "Jane  Doe", DecklistID: "def-456"},
+		{PlayerName: "Bob"},
+		{PlayerName: "bob"},
+		{PlayerName: "Carol"},
+	}
+	got := strings.Join(deckFileNames(decks), ",")
+	if want := "jane-doe-abc-123,jane-doe-def-456,bob-1,bob-2,carol"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema is the normalized layout written by export-sqlite. Players are
+// identified across tournaments by normalized name, like everywhere else in the
+// scraper. Games only record who won each game: melee.gg reports a game score,
+// not the order, so game_number lists the match winner's games first.
+const sqliteSchema = `
+CREATE TABLE tournaments (
+	id        TEXT PRIMARY KEY,
+	slug      TEXT NOT NULL,
+	name      TEXT NOT NULL,
+	format    TEXT NOT NULL,
+	date      TEXT NOT NULL,
+	completed INTEGER NOT NULL
+);
+
+CREATE TABLE players (
+	id              INTEGER PRIMARY KEY,
+	name            TEXT NOT NULL,
+	normalized_name TEXT NOT NULL UNIQUE,
+	melee_id        INTEGER
+);
+
+CREATE TABLE archetypes (
+	id   INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE rounds (
+	id            INTEGER PRIMARY KEY,
+	tournament_id TEXT NOT NULL REFERENCES tournaments(id),
+	number        INTEGER NOT NULL,
+	UNIQUE (tournament_id, number)
+);
+
+CREATE TABLE decks (
+	id                      INTEGER PRIMARY KEY,
+	tournament_id           TEXT NOT NULL REFERENCES tournaments(id),
+	player_id               INTEGER NOT NULL REFERENCES players(id),
+	archetype_id            INTEGER REFERENCES archetypes(id),
+	classified_archetype_id INTEGER REFERENCES archetypes(id),
+	decklist_id             TEXT,
+	main_hash               TEXT,
+	sideboard_hash          TEXT,
+	UNIQUE (tournament_id, player_id)
+);
+
+CREATE TABLE deck_cards (
+	deck_id   INTEGER NOT NULL REFERENCES decks(id),
+	board     TEXT NOT NULL CHECK (board IN ('main', 'sideboard')),
+	card_name TEXT NOT NULL,
+	quantity  INTEGER NOT NULL,
+	PRIMARY KEY (deck_id, board, card_name)
+);
+
+CREATE TABLE matches (
+	id            INTEGER PRIMARY KEY,
+	round_id      INTEGER NOT NULL REFERENCES rounds(id),
+	table_number  INTEGER NOT NULL,
+	player1_id    INTEGER NOT NULL REFERENCES players(id),
+	player2_id    INTEGER REFERENCES players(id),
+	winner_id     INTEGER REFERENCES players(id),
+	player1_games INTEGER NOT NULL,
+	player2_games INTEGER NOT NULL,
+	draw_games    INTEGER NOT NULL,
+	result        TEXT NOT NULL
+);
+
+CREATE TABLE games (
+	match_id    INTEGER NOT NULL REFERENCES matches(id),
+	game_number INTEGER NOT NULL,
+	winner_id   INTEGER REFERENCES players(id),
+	PRIMARY KEY (match_id, game_number)
+);
+
+CREATE INDEX matches_round ON matches(round_id);
+CREATE INDEX matches_player1 ON matches(player1_id);
+CREATE INDEX matches_player2 ON matches(player2_id);
+CREATE INDEX deck_cards_card ON deck_cards(card_name);
+CREATE INDEX decks_archetype ON decks(archetype_id);
+
+-- One row per player per match, with both decks, for matchup queries
+CREATE VIEW player_matches AS
+SELECT m.id AS match_id, r.tournament_id, r.number AS round,
+       m.player1_id AS player_id, m.player2_id AS opponent_id,
+       d.id AS deck_id, od.id AS opponent_deck_id,
+       a.name AS archetype, oa.name AS opponent_archetype,
+       CASE WHEN m.winner_id IS NULL THEN 'draw' WHEN m.winner_id = m.player1_id THEN 'win' ELSE 'loss' END AS outcome,
+       m.player1_games AS games_won, m.player2_games AS games_lost
+FROM matches m
+JOIN rounds r ON r.id = m.round_id
+LEFT JOIN decks d ON d.tournament_id = r.tournament_id AND d.player_id = m.player1_id
+LEFT JOIN decks od ON od.tournament_id = r.tournament_id AND od.player_id = m.player2_id
+LEFT JOIN archetypes a ON a.id = d.archetype_id
+LEFT JOIN archetypes oa ON oa.id = od.archetype_id
+WHERE m.player2_id IS NOT NULL
+UNION ALL
+SELECT m.id, r.tournament_id, r.number,
+       m.player2_id, m.player1_id,
+       od.id, d.id,
+       oa.name, a.name,
+       CASE WHEN m.winner_id IS NULL THEN 'draw' WHEN m.winner_id = m.player2_id THEN 'win' ELSE 'loss' END,
+       m.player2_games, m.player1_games
+FROM matches m
+JOIN rounds r ON r.id = m.round_id
+LEFT JOIN decks d ON d.tournament_id = r.tournament_id AND d.player_id = m.player1_id
+LEFT JOIN decks od ON od.tournament_id = r.tournament_id AND od.player_id = m.player2_id
+LEFT JOIN archetypes a ON a.id = d.archetype_id
+LEFT JOIN archetypes oa ON oa.id = od.archetype_id
+WHERE m.player2_id IS NOT NULL;
+`
+
+// sqliteExport inserts tournaments into an export database, assigning player and
+// archetype IDs as names are first seen
+type sqliteExport struct {
+	tx         *sql.Tx
+	players    map[string]int64
+	archetypes map[string]int64
+}
+
+// runExportSQLite implements the export-sqlite command
+func runExportSQLite(args []string) error {
+	fs := flag.NewFlagSet("export-sqlite", flag.ExitOnError)
+	outputFlag := fs.String("output", filepath.Join("exports", "protour.sqlite"), "Database file to write; an existing file is replaced")
+	fs.Parse(args)
+
+	registry, err := loadRegistry(filepath.Join(outputDir, registryFile))
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*outputFlag), 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	// Build into a temp file and rename it into place, like writeJSON, so readers
+	// never see a half-written database
+	tmpPath := *outputFlag + ".tmp"
+	os.Remove(tmpPath)
+	defer os.Remove(tmpPath)
+
+	if err := writeSQLiteExport(tmpPath, registry); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, *outputFlag); err != nil {
+		return fmt.Errorf("rename %s: %w", tmpPath, err)
+	}
+	log.Printf("    Saved %s", *outputFlag)
+	return nil
+}
+
+// writeSQLiteExport creates a database at path holding every registry tournament
+// that has a matches file
+func writeSQLiteExport(path string, registry Registry) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("create schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	export := &sqliteExport{tx: tx, players: make(map[string]int64), archetypes: make(map[string]int64)}
+	for _, t := range registry {
+		matches, err := loadMatches(t.ID)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("  Skipping tournament %s: not scraped yet", t.ID)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		decklists, err := loadDecklists(t.ID)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		var playerDecks map[string]string
+		if err := loadJSON(t.ID, "player-decks", &playerDecks); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if err := export.addTournament(t, matches, decklists, playerDecks); err != nil {
+			return fmt.Errorf("tournament %s: %w", t.ID, err)
+		}
+		log.Printf("  Exported tournament %s: %d rounds, %d decklists", t.ID, len(matches), len(decklists))
+	}
+
+	return tx.Commit()
+}
+
+// addTournament inserts one tournament with its rounds, matches, games and decks.
+// playerDecks labels players without a decklist, keyed by normalized name.
+func (e *sqliteExport) addTournament(t Tournament, allMatches map[int][]Match, decklists []DeckInfo, playerDecks map[string]string) error {
+	if _, err := e.tx.Exec(`INSERT INTO tournaments (id, slug, name, format, date, completed) VALUES (?, ?, ?, ?, ?, ?)`,
+		t.ID, t.Slug, t.Name, t.Format, t.Date, t.Completed); err != nil {
+		return err
+	}
+
+	// Decks first, so every player who registered one has a row before matches
+	// refer to them
+	seen := make(map[string]bool)
+	for _, deck := range decklists {
+		key := normalizePlayerName(deck.PlayerName)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err := e.addDeck(t.ID, deck); err != nil {
+			return err
+		}
+	}
+	displayNames := extractPlayerNamesFromMatches(allMatches)
+	names := make([]string, 0, len(playerDecks))
+	for name := range playerDecks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		playerName := displayNames[name]
+		if playerName == "" {
+			playerName = name
+		}
+		if err := e.addDeck(t.ID, DeckInfo{PlayerName: playerName, Archetype: playerDecks[name]}); err != nil {
+			return err
+		}
+	}
+
+	rounds := make([]int, 0, len(allMatches))
+	for round := range allMatches {
+		rounds = append(rounds, round)
+	}
+	sort.Ints(rounds)
+	for _, round := range rounds {
+		result, err := e.tx.Exec(`INSERT INTO rounds (tournament_id, number) VALUES (?, ?)`, t.ID, round)
+		if err != nil {
+			return err
+		}
+		roundID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for _, match := range allMatches[round] {
+			if err := e.addMatch(roundID, match); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addDeck inserts a deck and its cards
+func (e *sqliteExport) addDeck(tournamentID string, deck DeckInfo) error {
+	playerID, err := e.player(deck.PlayerName, 0)
+	if err != nil {
+		return err
+	}
+	archetypeID, err := e.archetype(deck.Archetype)
+	if err != nil {
+		return err
+	}
+	classifiedID, err := e.archetype(deck.ClassifiedArchetype)
+	if err != nil {
+		return err
+	}
+
+	result, err := e.tx.Exec(`INSERT INTO decks (tournament_id, player_id, archetype_id, classified_archetype_id, decklist_id, main_hash, sideboard_hash) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		tournamentID, playerID, archetypeID, classifiedID, nullString(deck.DecklistID), nullString(deck.MainHash), nullString(deck.SideboardHash))
+	if err != nil {
+		return err
+	}
+	deckID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, board := range []struct {
+		name  string
+		cards []CardInfo
+	}{{"main", deck.MainDeck}, {"sideboard", deck.Sideboard}} {
+		counts := cardCounts(board.cards)
+		for name, quantity := range counts {
+			if _, err := e.tx.Exec(`INSERT INTO deck_cards (deck_id, board, card_name, quantity) VALUES (?, ?, ?, ?)`,
+				deckID, board.name, name, quantity); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addMatch inserts a match and one games row per game played. Byes have no player2.
+func (e *sqliteExport) addMatch(roundID int64, match Match) error {
+	sides, ok := splitMatch(match)
+	if !ok {
+		return nil
+	}
+	var playerIDs [2]sql.NullInt64
+	for i, name := range sides.Players {
+		if name == "" {
+			continue
+		}
+		id, err := e.player(name, sides.MeleeIDs[i])
+		if err != nil {
+			return err
+		}
+		playerIDs[i] = sql.NullInt64{Int64: id, Valid: true}
+	}
+	var winnerID sql.NullInt64
+	if sides.Winner >= 0 {
+		winnerID = playerIDs[sides.Winner]
+	}
+
+	result, err := e.tx.Exec(`INSERT INTO matches (round_id, table_number, player1_id, player2_id, winner_id, player1_games, player2_games, draw_games, result) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		roundID, match.TableNumber, playerIDs[0], playerIDs[1], winnerID, sides.Games[0], sides.Games[1], sides.Draws, match.ResultString)
+	if err != nil {
+		return err
+	}
+	matchID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// The winner's games first, then the loser's, then drawn games
+	first := 0
+	if sides.Winner == 1 {
+		first = 1
+	}
+	game := 0
+	for _, g := range []struct {
+		count  int
+		winner sql.NullInt64
+	}{{sides.Games[first], playerIDs[first]}, {sides.Games[1-first], playerIDs[1-first]}, {sides.Draws, sql.NullInt64{}}} {
+		for i := 0; i < g.count; i++ {
+			game++
+			if _, err := e.tx.Exec(`INSERT INTO games (match_id, game_number, winner_id) VALUES (?, ?, ?)`, matchID, game, g.winner); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// player returns the ID of a player by name, inserting them on first sight
+func (e *sqliteExport) player(name string, meleeID int) (int64, error) {
+	key := normalizePlayerName(name)
+	if id, ok := e.players[key]; ok {
+		if meleeID != 0 {
+			if _, err := e.tx.Exec(`UPDATE players SET melee_id = ? WHERE id = ? AND melee_id IS NULL`, meleeID, id); err != nil {
+				return 0, err
+			}
+		}
+		return id, nil
+	}
+
+	var melee sql.NullInt64
+	if meleeID != 0 {
+		melee = sql.NullInt64{Int64: int64(meleeID), Valid: true}
+	}
+	result, err := e.tx.Exec(`INSERT INTO players (name, normalized_name, melee_id) VALUES (?, ?, ?)`, name, key, melee)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	e.players[key] = id
+	return id, nil
+}
+
+// archetype returns the ID of an archetype label, inserting it on first sight.
+// Empty labels are NULL.
+func (e *sqliteExport) archetype(name string) (sql.NullInt64, error) {
+	if name == "" {
+		return sql.NullInt64{}, nil
+	}
+	if id, ok := e.archetypes[name]; ok {
+		return sql.NullInt64{Int64: id, Valid: true}, nil
+	}
+	result, err := e.tx.Exec(`INSERT INTO archetypes (name) VALUES (?)`, name)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	e.archetypes[name] = id
+	return sql.NullInt64{Int64: id, Valid: true}, nil
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// exportTestDB creates an export database with one tournament and returns it open
+func exportTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "export.sqlite"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+
+	matches := map[int][]Match{
+		4: {
+			testMatch(t, "Alice", "Bob", "Bob won 2-1-0"),
+			testMatch(t, "Carol", "Dave", "1-1-0 Draw"),
+		},
+		5: {
+			testMatch(t, "Alice", "Carol", "Alice won 2-0-0"),
+		},
+	}
+	decklists := []DeckInfo{
+		{PlayerName: "Alice", Archetype: "Izzet Prowess", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 1, Name: "Opt"}}, Sideboard: []CardInfo{{Quantity: 2, Name: "Negate"}}},
+		{PlayerName: "Bob", Archetype: "Jeskai Control", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}}},
+	}
+	playerDecks := map[string]string{"alice": "Izzet Prowess", "bob": "Jeskai Control", "carol": "Jeskai Control"}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	export := &sqliteExport{tx: tx, players: make(map[string]int64), archetypes: make(map[string]int64)}
+	tournament := Tournament{ID: "1", Slug: "test", Name: "Test", Format: "Standard", Date: "2026-01-01"}
+	if err := export.addTournament(tournament, matches, decklists, playerDecks); err != nil {
+		t.Fatalf("addTournament returned error: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func queryInt(t *testing.T, db *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+func TestSQLiteExport_Tables(t *testing.T) {
+	db := exportTestDB(t)
+
+	counts := map[string]int{
+		"tournaments": 1,
+		"players":     4,
+		"archetypes":  2,
+		"rounds":      2,
+		"matches":     3,
+		"games":       7,
+		// Alice, Bob and Carol (from player-decks, without cards)
+		"decks":      3,
+		"deck_cards": 3,
+	}
+	for table, want := range counts {
+		if got := queryInt(t, db, "SELECT COUNT(*) FROM "+table); got != want {
+			t.Errorf("%s: expected %d rows, got %d", table, want, got)
+		}
+	}
+
+	// Duplicate card entries are merged
+	if got := queryInt(t, db, `SELECT quantity FROM deck_cards dc JOIN decks d ON d.id = dc.deck_id JOIN players p ON p.id = d.player_id WHERE p.name = 'Alice' AND board = 'main' AND card_name = 'Opt'`); got != 5 {
+		t.Errorf("expected 5 Opt, got %d", got)
+	}
+}
+
+func TestSQLiteExport_MatchScoresFollowPlayers(t *testing.T) {
+	db := exportTestDB(t)
+
+	// Bob won 2-1 as player 2, so player 1's score is the loser's
+	var p1Games, p2Games int
+	var winner string
+	err := db.QueryRow(`SELECT m.player1_games, m.player2_games, w.name FROM matches m JOIN players w ON w.id = m.winner_id JOIN rounds r ON r.id = m.round_id WHERE r.number = 4 AND m.winner_id IS NOT NULL`).Scan(&p1Games, &p2Games, &winner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if winner != "Bob" || p1Games != 1 || p2Games != 2 {
+		t.Errorf("expected Bob to win 1-2, got %s %d-%d", winner, p1Games, p2Games)
+	}
+
+	if got := queryInt(t, db, `SELECT COUNT(*) FROM games g JOIN players p ON p.id = g.winner_id WHERE p.name = 'Bob'`); got != 2 {
+		t.Errorf("expected Bob to win 2 games, got %d", got)
+	}
+	if got := queryInt(t, db, `SELECT COUNT(*) FROM matches WHERE winner_id IS NULL`); got != 1 {
+		t.Errorf("expected 1 drawn match, got %d", got)
+	}
+}
+
+func TestSQLiteExport_PlayerMatchesView(t *testing.T) {
+	db := exportTestDB(t)
+
+	if got := queryInt(t, db, `SELECT COUNT(*) FROM player_matches`); got != 6 {
+		t.Errorf("expected 2 rows per match, got %d", got)
+	}
+	// Izzet with 3+ Opt against Jeskai: Alice lost to Bob and beat Carol
+	rows, err := db.Query(`SELECT pm.outcome FROM player_matches pm
+		JOIN deck_cards dc ON dc.deck_id = pm.deck_id AND dc.board = 'main' AND dc.card_name = 'Opt' AND dc.quantity >= 3
+		WHERE pm.archetype = 'Izzet Prowess' AND pm.opponent_archetype = 'Jeskai Control'
+		ORDER BY pm.round`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var outcomes []string
+	for rows.Next() {
+		var outcome string
+		if err := rows.Scan(&outcome); err != nil {
+			t.Fatal(err)
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	if len(outcomes) != 2 || outcomes[0] != "loss" || outcomes[1] != "win" {
+		t.Errorf("expected [loss win], got %v", outcomes)
+	}
+}
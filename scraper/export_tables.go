@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// MatchRow is one row of matches.csv/.parquet: a match with both players'
+// archetypes. Player2 is empty for byes, which Player1 wins, and Winner is empty
+// for draws; the game counts are from each player's side.
+type MatchRow struct {
+	TournamentID     string `parquet:"tournament_id"`
+	Round            int    `parquet:"round"`
+	TableNumber      int    `parquet:"table_number"`
+	Player1          string `parquet:"player1"`
+	Player1Archetype string `parquet:"player1_archetype"`
+	Player2          string `parquet:"player2"`
+	Player2Archetype string `parquet:"player2_archetype"`
+	Winner           string `parquet:"winner"`
+	Player1Games     int    `parquet:"player1_games"`
+	Player2Games     int    `parquet:"player2_games"`
+	DrawGames        int    `parquet:"draw_games"`
+	Result           string `parquet:"result"`
+}
+
+// PlayerTournamentRow is one row of player-tournaments.csv/.parquet: a player's
+// archetype, deck and match record (byes excluded) in one tournament
+type PlayerTournamentRow struct {
+	TournamentID        string `parquet:"tournament_id"`
+	TournamentName      string `parquet:"tournament_name"`
+	TournamentDate      string `parquet:"tournament_date"`
+	Format              string `parquet:"format"`
+	Player              string `parquet:"player"`
+	Archetype           string `parquet:"archetype"`
+	ClassifiedArchetype string `parquet:"classified_archetype"`
+	Wins                int    `parquet:"wins"`
+	Losses              int    `parquet:"losses"`
+	Draws               int    `parquet:"draws"`
+	DecklistID          string `parquet:"decklist_id"`
+	MainHash            string `parquet:"main_hash"`
+	SideboardHash       string `parquet:"sideboard_hash"`
+}
+
+// DeckCardRow is one row of deck-cards.csv/.parquet: the copies of one card in one
+// board of a player's deck. Board is "main" or "sideboard".
+type DeckCardRow struct {
+	TournamentID string `parquet:"tournament_id"`
+	Player       string `parquet:"player"`
+	Archetype    string `parquet:"archetype"`
+	Board        string `parquet:"board"`
+	CardName     string `parquet:"card_name"`
+	Quantity     int    `parquet:"quantity"`
+}
+
+// matchSides is a match from each player's side. Players[1] is empty for a bye,
+// which Players[0] wins. Winner is 0 or 1, or -1 for a draw, and Games[i] is the
+// games player i won.
+type matchSides struct {
+	Players  [2]string
+	MeleeIDs [2]int
+	Winner   int
+	Games    [2]int
+	Draws    int
+}
+
+// splitMatch reads both sides of a match. A lone player is always Players[0].
+// It returns false for matches without any named player.
+func splitMatch(match Match) (matchSides, bool) {
+	var sides matchSides
+	for i := 0; i < 2 && i < len(match.Competitors); i++ {
+		players := match.Competitors[i].Team.Players
+		if len(players) > 0 {
+			sides.Players[i] = players[0].DisplayName
+			sides.MeleeIDs[i] = players[0].ID
+		}
+	}
+	if sides.Players[0] == "" {
+		if sides.Players[1] == "" {
+			return sides, false
+		}
+		sides.Players[0], sides.Players[1] = sides.Players[1], ""
+		sides.MeleeIDs[0], sides.MeleeIDs[1] = sides.MeleeIDs[1], 0
+	}
+
+	if sides.Players[1] == "" {
+		return sides, true
+	}
+
+	// parseMatchResult reports the winner's games first
+	winner, winnerGames, loserGames, draws := parseMatchResult(match.ResultString)
+	sides.Draws = draws
+	switch normalizePlayerName(winner) {
+	case "":
+		sides.Winner = -1
+		sides.Games = [2]int{winnerGames, loserGames}
+	case normalizePlayerName(sides.Players[0]):
+		sides.Winner = 0
+		sides.Games = [2]int{winnerGames, loserGames}
+	default:
+		sides.Winner = 1
+		sides.Games = [2]int{loserGames, winnerGames}
+	}
+	return sides, true
+}
+
+// matchRows flattens a tournament's matches, ordered by round and table.
+// playerArchetype is keyed by normalized player name.
+func matchRows(tournamentID string, allMatches map[int][]Match, playerArchetype map[string]string) []MatchRow {
+	rows := []MatchRow{}
+	for round, matches := range allMatches {
+		for _, match := range matches {
+			sides, ok := splitMatch(match)
+			if !ok {
+				continue
+			}
+			row := MatchRow{
+				TournamentID:     tournamentID,
+				Round:            round,
+				TableNumber:      match.TableNumber,
+				Player1:          sides.Players[0],
+				Player1Archetype: playerArchetype[normalizePlayerName(sides.Players[0])],
+				Player2:          sides.Players[1],
+				Player2Archetype: playerArchetype[normalizePlayerName(sides.Players[1])],
+				Player1Games:     sides.Games[0],
+				Player2Games:     sides.Games[1],
+				DrawGames:        sides.Draws,
+				Result:           match.ResultString,
+			}
+			if sides.Winner >= 0 {
+				row.Winner = sides.Players[sides.Winner]
+			}
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Round != rows[j].Round {
+			return rows[i].Round < rows[j].Round
+		}
+		return rows[i].TableNumber < rows[j].TableNumber
+	})
+	return rows
+}
+
+// playerTournamentRows lists every player who played a match or registered a
+// deck, ordered by name
+func playerTournamentRows(t Tournament, allMatches map[int][]Match, decklists []DeckInfo, playerArchetype map[string]string) []PlayerTournamentRow {
+	records := buildPlayerRecords(allMatches)
+	names := extractPlayerNamesFromMatches(allMatches)
+	decks := make(map[string]DeckInfo)
+	for _, deck := range decklists {
+		key := normalizePlayerName(deck.PlayerName)
+		decks[key] = deck
+		if names[key] == "" {
+			names[key] = deck.PlayerName
+		}
+	}
+
+	rows := make([]PlayerTournamentRow, 0, len(names))
+	for key, name := range names {
+		deck := decks[key]
+		row := PlayerTournamentRow{
+			TournamentID:        t.ID,
+			TournamentName:      t.Name,
+			TournamentDate:      t.Date,
+			Format:              t.Format,
+			Player:              name,
+			Archetype:           playerArchetype[key],
+			ClassifiedArchetype: deck.ClassifiedArchetype,
+			DecklistID:          deck.DecklistID,
+			MainHash:            deck.MainHash,
+			SideboardHash:       deck.SideboardHash,
+		}
+		if row.Archetype == "" {
+			row.Archetype = deck.Archetype
+		}
+		if record := records[key]; record != nil {
+			row.Wins, row.Losses, row.Draws = record.Wins, record.Losses, record.Draws
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Player < rows[j].Player })
+	return rows
+}
+
+// deckCardRows lists every card of every deck, merging repeated entries, ordered
+// by player, board and card name
+func deckCardRows(tournamentID string, decklists []DeckInfo) []DeckCardRow {
+	rows := []DeckCardRow{}
+	for _, deck := range decklists {
+		for _, board := range []struct {
+			name  string
+			cards []CardInfo
+		}{{"main", deck.MainDeck}, {"sideboard", deck.Sideboard}} {
+			for name, quantity := range cardCounts(board.cards) {
+				rows = append(rows, DeckCardRow{
+					TournamentID: tournamentID,
+					Player:       deck.PlayerName,
+					Archetype:    deck.Archetype,
+					Board:        board.name,
+					CardName:     name,
+					Quantity:     quantity,
+				})
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Player != rows[j].Player {
+			return rows[i].Player < rows[j].Player
+		}
+		if rows[i].Board != rows[j].Board {
+			return rows[i].Board < rows[j].Board
+		}
+		return rows[i].CardName < rows[j].CardName
+	})
+	return rows
+}
+
+// tableColumns returns the column names of a row type, from its parquet tags
+func tableColumns(rowType reflect.Type) []string {
+	columns := make([]string, rowType.NumField())
+	for i := range columns {
+		name, _, _ := strings.Cut(rowType.Field(i).Tag.Get("parquet"), ",")
+		columns[i] = name
+	}
+	return columns
+}
+
+// writeTableCSV writes rows as CSV with a header of tableColumns
+func writeTableCSV[T any](path string, rows []T) error {
+	return writeFileAtomic(path, func(file io.Writer) error {
+		w := csv.NewWriter(file)
+		if err := w.Write(tableColumns(reflect.TypeOf((*T)(nil)).Elem())); err != nil {
+			return err
+		}
+		record := []string{}
+		for _, row := range rows {
+			v := reflect.ValueOf(row)
+			record = record[:0]
+			for i := 0; i < v.NumField(); i++ {
+				switch field := v.Field(i); field.Kind() {
+				case reflect.Int:
+					record = append(record, strconv.FormatInt(field.Int(), 10))
+				default:
+					record = append(record, field.String())
+				}
+			}
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	})
+}
+
+// writeTableParquet writes rows as a zstd-compressed Parquet file
+func writeTableParquet[T any](path string, rows []T) error {
+	return writeFileAtomic(path, func(file io.Writer) error {
+		w := parquet.NewGenericWriter[T](file, parquet.Compression(&parquet.Zstd))
+		if _, err := w.Write(rows); err != nil {
+			return err
+		}
+		return w.Close()
+	})
+}
+
+// writeTable writes rows to dir/name.{csv,parquet} in each requested format
+func writeTable[T any](dir, name string, rows []T, formats []string) error {
+	for _, format := range formats {
+		path := filepath.Join(dir, name+"."+format)
+		var err error
+		switch format {
+		case "csv":
+			err = writeTableCSV(path, rows)
+		case "parquet":
+			err = writeTableParquet(path, rows)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runExportTables implements the export-tables command
+func runExportTables(args []string) error {
+	fs := flag.NewFlagSet("export-tables", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Only export this tournament. Defaults to every tournament in the registry.")
+	formatFlag := fs.String("format", "all", "Export format: csv, parquet or all")
+	outputFlag := fs.String("output", "exports", "Output directory")
+	fs.Parse(args)
+
+	var formats []string
+	switch *formatFlag {
+	case "csv", "parquet":
+		formats = []string{*formatFlag}
+	case "all":
+		formats = []string{"csv", "parquet"}
+	default:
+		return fmt.Errorf("unknown format %q (want csv, parquet or all)", *formatFlag)
+	}
+
+	registry, err := loadRegistry(filepath.Join(outputDir, registryFile))
+	if err != nil {
+		return err
+	}
+
+	matches := []MatchRow{}
+	players := []PlayerTournamentRow{}
+	cards := []DeckCardRow{}
+	found := false
+	for _, t := range registry {
+		if *tournamentFlag != "" && t.ID != *tournamentFlag {
+			continue
+		}
+		found = true
+
+		allMatches, err := loadMatches(t.ID)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("  Skipping tournament %s: not scraped yet", t.ID)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		decklists, err := loadDecklists(t.ID)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		var playerArchetype map[string]string
+		if err := loadJSON(t.ID, "player-decks", &playerArchetype); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		matches = append(matches, matchRows(t.ID, allMatches, playerArchetype)...)
+		players = append(players, playerTournamentRows(t, allMatches, decklists, playerArchetype)...)
+		cards = append(cards, deckCardRows(t.ID, decklists)...)
+	}
+	if !found {
+		return fmt.Errorf("tournament %s is not in the registry", *tournamentFlag)
+	}
+
+	if err := os.MkdirAll(*outputFlag, 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	if err := writeTable(*outputFlag, "matches", matches, formats); err != nil {
+		return err
+	}
+	if err := writeTable(*outputFlag, "player-tournaments", players, formats); err != nil {
+		return err
+	}
+	if err := writeTable(*outputFlag, "deck-cards", cards, formats); err != nil {
+		return err
+	}
+	log.Printf("Exported %d matches, %d player-tournaments and %d deck cards", len(matches), len(players), len(cards))
+	return nil
+}
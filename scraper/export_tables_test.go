@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// The columns are a documented contract for pandas/DuckDB users: append new
+// columns at the end and update the README rather than renaming or reordering
+func TestTableColumns_Stable(t *testing.T) {
+	tests := map[reflect.Type][]string{
+		reflect.TypeOf(MatchRow{}): {"tournament_id", "round", "table_number", "player1", "player1_archetype", "player2", "player2_archetype",
+			"winner", "player1_games", "player2_games", "draw_games", "result"},
+		reflect.TypeOf(PlayerTournamentRow{}): {"tournament_id", "tournament_name", "tournament_date", "format", "player", "archetype",
+			"classified_archetype", "wins", "losses", "draws", "decklist_id", "main_hash", "sideboard_hash"},
+		reflect.TypeOf(DeckCardRow{}): {"tournament_id", "player", "archetype", "board", "card_name", "quantity"},
+	}
+	for rowType, want := range tests {
+		if got := tableColumns(rowType); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s columns changed:\n got %v\nwant %v", rowType.Name(), got, want)
+		}
+	}
+}
+
+func TestSplitMatch(t *testing.T) {
+	sides, ok := splitMatch(testMatch(t, "Alice", "Bob", "Bob won 2-1-0"))
+	if !ok || sides.Winner != 1 || sides.Games != [2]int{1, 2} {
+		t.Errorf("expected Bob to win 1-2, got %+v", sides)
+	}
+
+	sides, ok = splitMatch(testMatch(t, "Alice", "Bob", "1-1-1 Draw"))
+	if !ok || sides.Winner != -1 || sides.Games != [2]int{1, 1} || sides.Draws != 1 {
+		t.Errorf("expected a 1-1-1 draw, got %+v", sides)
+	}
+
+	sides, ok = splitMatch(testMatch(t, "", "Carol", "Carol was assigned a bye"))
+	if !ok || sides.Players != [2]string{"Carol", ""} || sides.Winner != 0 || sides.Games != [2]int{} {
+		t.Errorf("expected a bye won by Carol as player 1, got %+v", sides)
+	}
+
+	if _, ok := splitMatch(Match{}); ok {
+		t.Errorf("expected a match without players to be skipped")
+	}
+}
+
+func TestMatchRows(t *testing.T) {
+	matches := map[int][]Match{
+		5: {testMatch(t, "Alice", "Carol", "Alice won 2-0-0")},
+		4: {testMatch(t, "Alice", "Bob", "Bob won 2-1-0")},
+	}
+	rows := matchRows("1", matches, map[string]string{"alice": "Izzet Prowess", "bob": "Jeskai Control"})
+
+	if len(rows) != 2 || rows[0].Round != 4 || rows[1].Round != 5 {
+		t.Fatalf("expected rows ordered by round, got %+v", rows)
+	}
+	want := MatchRow{TournamentID: "1", Round: 4, TableNumber: 1, Player1: "Alice", Player1Archetype: "Izzet Prowess",
+		Player2: "Bob", Player2Archetype: "Jeskai Control", Winner: "Bob", Player1Games: 1, Player2Games: 2, Result: "Bob won 2-1-0"}
+	if rows[0] != want {
+		t.Errorf("got %+v\nwant %+v", rows[0], want)
+	}
+}
+
+func TestPlayerTournamentRows(t *testing.T) {
+	matches := map[int][]Match{4: {testMatch(t, "Alice", "Bob", "Bob won 2-1-0")}}
+	decklists := []DeckInfo{
+		{PlayerName: "Bob", Archetype: "Jeskai", DecklistID: "d1", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}}},
+		{PlayerName: "Dave", Archetype: "Mono Red"},
+	}
+	rows := playerTournamentRows(Tournament{ID: "1", Name: "Test"}, matches, decklists, map[string]string{"bob": "Jeskai Control"})
+
+	if len(rows) != 3 {
+		t.Fatalf("expected Alice, Bob and Dave, got %+v", rows)
+	}
+	bob := rows[1]
+	if bob.Player != "Bob" || bob.Archetype != "Jeskai Control" || bob.Wins != 1 || bob.Losses != 0 || bob.DecklistID != "d1" {
+		t.Errorf("unexpected row for Bob: %+v", bob)
+	}
+	if dave := rows[2]; dave.Archetype != "Mono Red" || dave.Wins+dave.Losses+dave.Draws != 0 {
+		t.Errorf("expected Dave's deck archetype and no matches, got %+v", dave)
+	}
+}
+
+func TestWriteTable_CSVAndParquet(t *testing.T) {
+	dir := t.TempDir()
+	rows := deckCardRows("1", []DeckInfo{
+		{PlayerName: "Alice", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 2, Name: "Opt"}}, Sideboard: []CardInfo{{Quantity: 1, Name: "Negate"}}},
+	})
+	if err := writeTable(dir, "deck-cards", rows, []string{"csv", "parquet"}); err != nil {
+		t.Fatalf("writeTable returned error: %v", err)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "deck-cards.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"tournament_id", "player", "archetype", "board", "card_name", "quantity"},
+		{"1", "Alice", "Izzet", "main", "Opt", "6"},
+		{"1", "Alice", "Izzet", "sideboard", "Negate", "1"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("csv:\n got %v\nwant %v", records, want)
+	}
+
+	read, err := parquet.ReadFile[DeckCardRow](filepath.Join(dir, "deck-cards.parquet"))
+	if err != nil {
+		t.Fatalf("read parquet: %v", err)
+	}
+	if !reflect.DeepEqual(read, rows) {
+		t.Errorf("parquet:\n got %+v\nwant %+v", read, rows)
+	}
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyDir holds per-tournament snapshot history under outputDir
+const historyDir = "history"
+
+// snapshotIDLayout names snapshots by scrape start time, so they sort chronologically
+const snapshotIDLayout = "20060102T150405Z"
+
+// historyStore keeps the snapshots of one tournament:
+//
+//	{root}/snapshots/{snapshot ID}.json  the scrape's manifest
+//	{root}/files/{kind}-{sha256}.json    file contents, shared by every snapshot with that hash
+//
+// Storing contents by hash means files a scrape didn't change take no extra space.
+type historyStore struct {
+	root string
+}
+
+// snapshot is one stored scrape of a tournament
+type snapshot struct {
+	ID       string
+	Manifest *OutputManifest
+}
+
+// tournamentHistory returns the history store of a tournament in outputDir
+func tournamentHistory(tournamentID string) historyStore {
+	return historyStore{root: filepath.Join(outputDir, historyDir, tournamentID)}
+}
+
+// blobPath is where the contents of a file of kind with the given hash are kept
+func (h historyStore) blobPath(kind, sha string) string {
+	return filepath.Join(h.root, "files", fmt.Sprintf("%s-%s.json", kind, sha))
+}
+
+// save stores a scrape's files and manifest as a snapshot. sourcePath gives the
+// path of each kind's current file. Nothing is saved when every file except the
+// run report is unchanged since the latest snapshot; saved is false then.
+func (h historyStore) save(manifest *OutputManifest, sourcePath func(kind string) string) (id string, saved bool, err error) {
+	snapshots, err := h.list()
+	if err != nil {
+		return "", false, err
+	}
+	if len(snapshots) > 0 && sameSnapshotData(snapshots[len(snapshots)-1].Manifest, manifest) {
+		return snapshots[len(snapshots)-1].ID, false, nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(h.root, "files"), 0755); err != nil {
+		return "", false, fmt.Errorf("create history directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(h.root, "snapshots"), 0755); err != nil {
+		return "", false, fmt.Errorf("create history directory: %w", err)
+	}
+
+	for _, kind := range manifestKinds(manifest) {
+		blob := h.blobPath(kind, manifest.Files[kind].SHA256)
+		if _, err := os.Stat(blob); err == nil {
+			continue
+		}
+		if err := copyFileAtomic(sourcePath(kind), blob); err != nil {
+			return "", false, err
+		}
+	}
+
+	id = manifest.ScrapedAt.UTC().Format(snapshotIDLayout)
+	if err := writeJSON(filepath.Join(h.root, "snapshots", id+".json"), manifest); err != nil {
+		return "", false, err
+	}
+	return id, true, nil
+}
+
+// sameSnapshotData reports whether two manifests list the same file contents,
+// ignoring the run report, which changes on every scrape
+func sameSnapshotData(a, b *OutputManifest) bool {
+	count := func(m *OutputManifest) int {
+		n := len(m.Files)
+		if _, ok := m.Files["report"]; ok {
+			n--
+		}
+		return n
+	}
+	if count(a) != count(b) {
+		return false
+	}
+	for kind, file := range b.Files {
+		if kind != "report" && a.Files[kind].SHA256 != file.SHA256 {
+			return false
+		}
+	}
+	return true
+}
+
+// manifestKinds returns the kinds in a manifest in a stable order
+func manifestKinds(manifest *OutputManifest) []string {
+	kinds := make([]string, 0, len(manifest.Files))
+	for kind := range manifest.Files {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// copyFileAtomic copies src to dst through writeFileAtomic
+func copyFileAtomic(src, dst string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", src, err)
+	}
+	defer file.Close()
+	return writeFileAtomic(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, file)
+		return err
+	})
+}
+
+// list returns every snapshot, oldest first
+func (h historyStore) list() ([]snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(h.root, "snapshots", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	snapshots := make([]snapshot, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		var manifest OutputManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		snapshots = append(snapshots, snapshot{ID: strings.TrimSuffix(filepath.Base(path), ".json"), Manifest: &manifest})
+	}
+	return snapshots, nil
+}
+
+// find returns the snapshot with the given ID, or the latest one taken at or
+// before at when id is empty
+func (h historyStore) find(id string, at time.Time) (snapshot, error) {
+	snapshots, err := h.list()
+	if err != nil {
+		return snapshot{}, err
+	}
+	if len(snapshots) == 0 {
+		return snapshot{}, fmt.Errorf("no snapshots in %s", h.root)
+	}
+	if id != "" {
+		for _, s := range snapshots {
+			if s.ID == id {
+				return s, nil
+			}
+		}
+		return snapshot{}, fmt.Errorf("snapshot %s not found in %s", id, h.root)
+	}
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if !snapshots[i].Manifest.ScrapedAt.After(at) {
+			return snapshots[i], nil
+		}
+	}
+	return snapshot{}, fmt.Errorf("no snapshot taken at or before %s", at.Format(time.RFC3339))
+}
+
+// load reads one of a snapshot's files into v. Errors for kinds the snapshot
+// doesn't have wrap fs.ErrNotExist, like loadJSON.
+func (h historyStore) load(s snapshot, kind string, v interface{}) error {
+	file, ok := s.Manifest.Files[kind]
+	if !ok {
+		return fmt.Errorf("snapshot %s has no %s file: %w", s.ID, kind, os.ErrNotExist)
+	}
+	path := h.blobPath(kind, file.SHA256)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	if sum := sha256Hex(data); sum != file.SHA256 {
+		return fmt.Errorf("%s is corrupt: sha256 %s, snapshot %s lists %s", path, sum, s.ID, file.SHA256)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// minMatchWinRate is the floor on a player's match win rate when computing their
+// opponents' OMW%, as in the Magic Tournament Rules
+const minMatchWinRate = 0.33
+
+// Standing is one player's place in the standings: 3 points per match win
+// (byes included) and 1 per draw. OMWPercent is the mean match win rate of the
+// player's opponents, the first tiebreaker; game-based tiebreakers aren't applied.
+type Standing struct {
+	Rank       int     `json:"rank"`
+	Player     string  `json:"player"`
+	Archetype  string  `json:"archetype"`
+	Points     int     `json:"points"`
+	Wins       int     `json:"wins"`
+	Losses     int     `json:"losses"`
+	Draws      int     `json:"draws"`
+	OMWPercent float64 `json:"omwPercent"`
+}
+
+// AsOfReport is a tournament's stats and standings rebuilt from a snapshot,
+// optionally cut off after a round
+type AsOfReport struct {
+	TournamentID string           `json:"tournamentId"`
+	Snapshot     string           `json:"snapshot,omitempty"`
+	ScrapedAt    time.Time        `json:"scrapedAt"`
+	ThroughRound int              `json:"throughRound,omitempty"`
+	Rounds       []int            `json:"rounds"`
+	Stats        *TournamentStats `json:"stats"`
+	Standings    []Standing       `json:"standings"`
+}
+
+// matchesThroughRound keeps the rounds up to and including round; 0 keeps every round
+func matchesThroughRound(allMatches map[int][]Match, round int) map[int][]Match {
+	if round == 0 {
+		return allMatches
+	}
+	kept := make(map[int][]Match)
+	for r, matches := range allMatches {
+		if r <= round {
+			kept[r] = matches
+		}
+	}
+	return kept
+}
+
+// buildStandings ranks every player by points, then by opponents' match win
+// percentage (OMW%). Players equal on both share a rank and are listed by name;
+// game win percentages, the later tiebreakers, aren't computed.
+func buildStandings(allMatches map[int][]Match, playerArchetype map[string]string) []Standing {
+	byPlayer := make(map[string]*Standing)
+	opponents := make(map[string][]string)
+	standing := func(name string) *Standing {
+		key := normalizePlayerName(name)
+		if byPlayer[key] == nil {
+			byPlayer[key] = &Standing{Player: name, Archetype: playerArchetype[key]}
+		}
+		return byPlayer[key]
+	}
+
+	for _, matches := range allMatches {
+		for _, match := range matches {
+			sides, ok := splitMatch(match)
+			if !ok || match.ResultString == "" {
+				continue
+			}
+			if sides.Players[1] == "" {
+				standing(sides.Players[0]).Wins++
+				continue
+			}
+			p1, p2 := standing(sides.Players[0]), standing(sides.Players[1])
+			k1, k2 := normalizePlayerName(sides.Players[0]), normalizePlayerName(sides.Players[1])
+			opponents[k1] = append(opponents[k1], k2)
+			opponents[k2] = append(opponents[k2], k1)
+			switch sides.Winner {
+			case 0:
+				p1.Wins++
+				p2.Losses++
+			case 1:
+				p2.Wins++
+				p1.Losses++
+			default:
+				p1.Draws++
+				p2.Draws++
+			}
+		}
+	}
+
+	matchWinRate := make(map[string]float64, len(byPlayer))
+	for key, s := range byPlayer {
+		s.Points = 3*s.Wins + s.Draws
+		played := s.Wins + s.Losses + s.Draws
+		matchWinRate[key] = math.Max(minMatchWinRate, float64(s.Points)/float64(3*played))
+	}
+
+	standings := make([]Standing, 0, len(byPlayer))
+	for key, s := range byPlayer {
+		if len(opponents[key]) > 0 {
+			var sum float64
+			for _, opponent := range opponents[key] {
+				sum += matchWinRate[opponent]
+			}
+			s.OMWPercent = math.Round(sum/float64(len(opponents[key]))*10000) / 100
+		}
+		standings = append(standings, *s)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Points != standings[j].Points {
+			return standings[i].Points > standings[j].Points
+		}
+		if standings[i].OMWPercent != standings[j].OMWPercent {
+			return standings[i].OMWPercent > standings[j].OMWPercent
+		}
+		return standings[i].Player < standings[j].Player
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && standings[i].Points == standings[i-1].Points && standings[i].OMWPercent == standings[i-1].OMWPercent {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+	return standings
+}
+
+// buildAsOfReport rebuilds stats and standings from matches and archetype labels
+func buildAsOfReport(tournamentID string, allMatches map[int][]Match, playerArchetype map[string]string, throughRound int) *AsOfReport {
+	allMatches = matchesThroughRound(allMatches, throughRound)
+	report := &AsOfReport{
+		TournamentID: tournamentID,
+		ThroughRound: throughRound,
+		Rounds:       []int{},
+		Stats:        aggregateStats(allMatches, playerArchetype),
+		Standings:    buildStandings(allMatches, playerArchetype),
+	}
+	for round := range allMatches {
+		report.Rounds = append(report.Rounds, round)
+	}
+	sort.Ints(report.Rounds)
+	return report
+}
+
+// runHistory implements the history command
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Tournament ID whose snapshots to list (required)")
+	fs.Parse(args)
+
+	if *tournamentFlag == "" {
+		return fmt.Errorf("-tournament is required")
+	}
+
+	snapshots, err := tournamentHistory(*tournamentFlag).list()
+	if err != nil {
+		return err
+	}
+	var previous *OutputManifest
+	for _, s := range snapshots {
+		var changed []string
+		for _, kind := range manifestKinds(s.Manifest) {
+			if kind == "report" {
+				continue
+			}
+			if previous == nil || previous.Files[kind].SHA256 != s.Manifest.Files[kind].SHA256 {
+				changed = append(changed, kind)
+			}
+		}
+		fmt.Printf("%s  %4d matches  scraper %-16s changed: %s\n",
+			s.ID, s.Manifest.Files["matches"].Rows, s.Manifest.ScraperVersion, strings.Join(changed, ", "))
+		previous = s.Manifest
+	}
+	log.Printf("%d snapshots of tournament %s", len(snapshots), *tournamentFlag)
+	return nil
+}
+
+// runAsOf implements the as-of command
+func runAsOf(args []string) error {
+	fs := flag.NewFlagSet("as-of", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Tournament ID (required)")
+	snapshotFlag := fs.String("snapshot", "", "Snapshot ID from the history command")
+	atFlag := fs.String("at", "", "Use the latest snapshot taken at or before this RFC 3339 time")
+	roundFlag := fs.Int("round", 0, "Only count matches up to and including this round")
+	outputFlag := fs.String("output", "", "Write the report to this file instead of stdout")
+	fs.Parse(args)
+
+	if *tournamentFlag == "" {
+		return fmt.Errorf("-tournament is required")
+	}
+	if *snapshotFlag != "" && *atFlag != "" {
+		return fmt.Errorf("-snapshot and -at are mutually exclusive")
+	}
+
+	var allMatches map[int][]Match
+	var playerArchetype map[string]string
+	var snapshotID string
+	var scrapedAt time.Time
+	if *snapshotFlag == "" && *atFlag == "" {
+		// Current files, e.g. to cut them off after a round
+		var err error
+		if allMatches, err = loadMatches(*tournamentFlag); err != nil {
+			return err
+		}
+		if err := loadJSON(*tournamentFlag, "player-decks", &playerArchetype); err != nil {
+			return err
+		}
+		if manifest, err := loadOutputManifest(*tournamentFlag); err == nil {
+			scrapedAt = manifest.ScrapedAt
+		}
+	} else {
+		at := time.Now()
+		if *atFlag != "" {
+			var err error
+			if at, err = time.Parse(time.RFC3339, *atFlag); err != nil {
+				return fmt.Errorf("invalid -at: %w", err)
+			}
+		}
+		history := tournamentHistory(*tournamentFlag)
+		s, err := history.find(*snapshotFlag, at)
+		if err != nil {
+			return err
+		}
+		if err := history.load(s, "matches", &allMatches); err != nil {
+			return err
+		}
+		if err := history.load(s, "player-decks", &playerArchetype); err != nil {
+			return err
+		}
+		snapshotID, scrapedAt = s.ID, s.Manifest.ScrapedAt
+	}
+
+	report := buildAsOfReport(*tournamentFlag, allMatches, playerArchetype, *roundFlag)
+	report.Snapshot = snapshotID
+	report.ScrapedAt = scrapedAt
+	if *outputFlag != "" {
+		return writeJSON(*outputFlag, report)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeScrape writes a tournament's matches and player-decks to dir and returns
+// a manifest for them, like one scrape would
+func writeScrape(t *testing.T, dir string, scrapedAt time.Time, matches map[int][]Match, report string) *OutputManifest {
+	t.Helper()
+	files := map[string]interface{}{
+		"matches":      matches,
+		"player-decks": map[string]string{"alice": "Izzet Prowess", "bob": "Jeskai Control", "carol": "Jeskai Control"},
+		"report":       map[string]string{"startedAt": report},
+	}
+	manifest := &OutputManifest{SchemaVersion: manifestSchemaVersion, TournamentID: "1", ScrapedAt: scrapedAt, Files: make(map[string]ManifestFile)}
+	for kind, data := range files {
+		path := filepath.Join(dir, kind+".json")
+		if err := writeJSON(path, data); err != nil {
+			t.Fatal(err)
+		}
+		sum, size, err := fileSHA256(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest.Files[kind] = ManifestFile{File: filepath.Base(path), SHA256: sum, Bytes: size}
+	}
+	return manifest
+}
+
+func TestHistoryStore_DeduplicatesUnchangedScrapes(t *testing.T) {
+	data := t.TempDir()
+	history := historyStore{root: filepath.Join(t.TempDir(), "history", "1")}
+	source := func(kind string) string { return filepath.Join(data, kind+".json") }
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	round4 := map[int][]Match{4: {testMatch(t, "Alice", "Bob", "Alice won 2-0-0")}}
+	first := writeScrape(t, data, start, round4, "9:00")
+	id, saved, err := history.save(first, source)
+	if err != nil || !saved || id != "20260501T090000Z" {
+		t.Fatalf("first save: id %q, saved %v, err %v", id, saved, err)
+	}
+
+	// Only the run report differs: no new snapshot
+	second := writeScrape(t, data, start.Add(10*time.Minute), round4, "9:10")
+	if id, saved, err := history.save(second, source); err != nil || saved || id != "20260501T090000Z" {
+		t.Fatalf("unchanged save: id %q, saved %v, err %v", id, saved, err)
+	}
+
+	// A new round: new snapshot, player-decks stored once
+	rounds45 := map[int][]Match{
+		4: round4[4],
+		5: {testMatch(t, "Bob", "Carol", "Carol won 2-1-0")},
+	}
+	third := writeScrape(t, data, start.Add(time.Hour), rounds45, "10:00")
+	if _, saved, err := history.save(third, source); err != nil || !saved {
+		t.Fatalf("changed save: saved %v, err %v", saved, err)
+	}
+
+	snapshots, err := history.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(snapshots))
+	}
+	blobs, _ := filepath.Glob(filepath.Join(history.root, "files", "player-decks-*.json"))
+	if len(blobs) != 1 {
+		t.Errorf("expected unchanged player-decks to be stored once, got %v", blobs)
+	}
+
+	// Before the second snapshot, only round 4 was known
+	s, err := history.find("", start.Add(30*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var matches map[int][]Match
+	if err := history.load(s, "matches", &matches); err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || len(matches[4]) != 1 {
+		t.Errorf("expected round 4 only as of 9:30, got %v", matches)
+	}
+
+	// A blob that no longer matches its hash is refused
+	blob := history.blobPath("matches", s.Manifest.Files["matches"].SHA256)
+	if filepath.Base(blob) != "matches-"+s.Manifest.Files["matches"].SHA256+".json" {
+		t.Errorf("blobs should be named by the full hash, got %s", blob)
+	}
+	if err := os.WriteFile(blob, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := history.load(s, "matches", &matches); err == nil {
+		t.Error("expected an error for a corrupt blob")
+	}
+
+	if _, err := history.find("", start.Add(-time.Minute)); err == nil {
+		t.Errorf("expected no snapshot before the first scrape")
+	}
+	if _, err := history.find("20260501T100000Z", time.Time{}); err != nil {
+		t.Errorf("expected to find the second snapshot by ID: %v", err)
+	}
+}
+
+func TestHistoryStore_LoadMissingKind(t *testing.T) {
+	history := historyStore{root: t.TempDir()}
+	s := snapshot{ID: "x", Manifest: &OutputManifest{Files: map[string]ManifestFile{}}}
+	var v interface{}
+	if err := history.load(s, "stats", &v); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
+
+func TestBuildAsOfReport_ThroughRound(t *testing.T) {
+	matches := map[int][]Match{
+		4: {
+			testMatch(t, "Alice", "Bob", "Alice won 2-0-0"),
+			testMatch(t, "Carol", "", "Carol was assigned a bye"),
+		},
+		5: {
+			testMatch(t, "Alice", "Carol", "1-1-1 Draw"),
+			testMatch(t, "Bob", "Dave", "Dave won 2-1-0"),
+		},
+	}
+	archetypes := map[string]string{"alice": "Izzet Prowess", "bob": "Jeskai Control", "carol": "Jeskai Control", "dave": "Mono Red"}
+
+	report := buildAsOfReport("1", matches, archetypes, 4)
+	if len(report.Rounds) != 1 || report.Rounds[0] != 4 {
+		t.Errorf("expected round 4 only, got %v", report.Rounds)
+	}
+	if izzet := report.Stats.Archetypes["Izzet Prowess"]; izzet == nil || izzet.Wins != 1 || izzet.Draws != 0 {
+		t.Errorf("expected Izzet 1-0 through round 4, got %+v", izzet)
+	}
+	// Alice and Carol (bye) are on 3 points; Alice's OMW% (Bob's floored 33%) breaks the tie
+	if len(report.Standings) != 3 || report.Standings[0].Player != "Alice" || report.Standings[0].OMWPercent != 33 ||
+		report.Standings[1].Player != "Carol" || report.Standings[1].Rank != 2 || report.Standings[2].Rank != 3 {
+		t.Errorf("unexpected standings: %+v", report.Standings)
+	}
+
+	full := buildAsOfReport("1", matches, archetypes, 0)
+	// Both on 4 points: Carol's only opponent, Alice, beats Alice's average of Bob and Carol
+	if full.Standings[0].Player != "Carol" || full.Standings[0].OMWPercent != 66.67 ||
+		full.Standings[1].Player != "Alice" || full.Standings[1].Points != 4 || full.Standings[1].Draws != 1 || full.Standings[1].OMWPercent != 49.83 {
+		t.Errorf("expected Carol then Alice on 4 points, got %+v", full.Standings[:2])
+	}
+}
+
+func TestBuildStandings_SharedRank(t *testing.T) {
+	matches := map[int][]Match{
+		4: {
+			testMatch(t, "Alice", "Bob", "Alice won 2-0-0"),
+			testMatch(t, "Carol", "Dave", "Carol won 2-1-0"),
+		},
+	}
+	standings := buildStandings(matches, nil)
+
+	// Alice and Carol have equal points and OMW%: same rank, listed by name
+	if len(standings) != 4 || standings[0].Player != "Alice" || standings[0].Rank != 1 ||
+		standings[1].Player != "Carol" || standings[1].Rank != 1 || standings[2].Rank != 3 {
+		t.Errorf("unexpected standings: %+v", standings)
+	}
+	// Bob and Dave lost to 3-0 players
+	if standings[2].OMWPercent != 100 || standings[3].OMWPercent != 100 {
+		t.Errorf("expected 100%% OMW for the losers, got %+v", standings[2:])
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaDir is where the schema command writes the generated JSON Schemas, under outputDir
+const schemaDir = "schemas"
+
+// outputSchema ties a data file to the Go type it is encoded from. Tournament files
+// are named tournament-{id}-{kind}.json; other files are {kind}.json.
+type outputSchema struct {
+	kind        string
+	description string
+	goType      reflect.Type
+}
+
+var outputSchemas = []outputSchema{
+	{"tournaments", "Tournament registry (tournaments.json)", reflect.TypeOf(Registry{})},
+	{"matches", "Match results keyed by round number", reflect.TypeOf(map[int][]Match{})},
+	{"decklists", "Every player's decklist", reflect.TypeOf([]DeckInfo{})},
+	{"player-decks", "Player name to archetype label", reflect.TypeOf(map[string]string{})},
+	{"stats", "Archetype win rates and matchups", reflect.TypeOf(TournamentStats{})},
+	{"archetype-changes", "Archetype labels changed by aliases and overrides", reflect.TypeOf([]ArchetypeChange{})},
+	{"classification", "Rule-based archetype classification report", reflect.TypeOf(ClassificationReport{})},
+	{"archetype-decks", "Per-archetype card inclusion rates and aggregate decklists", reflect.TypeOf(TournamentArchetypeDecks{})},
+	{"cards", "Per-card deck counts and win rates", reflect.TypeOf(TournamentCardStats{})},
+	{"report", "Run report of the latest scrape", reflect.TypeOf(RunReport{})},
+	{"clusters", "Decklist clusters from the cluster command", reflect.TypeOf(ClusterReport{})},
+	{"duplicates", "Identical and near-identical 75s from the duplicates command (duplicates.json with -all)", reflect.TypeOf(DuplicateReport{})},
+	{"cooccurrence", "Card co-occurrence graphs from the cooccurrence command", reflect.TypeOf(TournamentCooccurrence{})},
+	{"manifest", "Files written by the latest scrape, with hashes and schema versions", reflect.TypeOf(OutputManifest{})},
+	{"match-fields", "Undeclared melee.gg match fields and where each was first seen (match-fields.json)", reflect.TypeOf(map[string]FieldSighting{})},
+}
+
+// generateJSONSchema builds a JSON Schema (draft 2020-12) document for a data file.
+// Named struct types become $defs. Fields without omitempty are required and
+// structs allow no other properties (except API types keeping undeclared
+// fields, see rawFields), so the schema is an exact contract for the
+// Go encoding; nil slices, maps and pointers encode as null and are allowed.
+func generateJSONSchema(s outputSchema) map[string]interface{} {
+	g := &schemaGenerator{defs: make(map[string]interface{})}
+	root := g.schemaFor(s.goType)
+
+	doc := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         s.kind + ".schema.json",
+		"title":       s.kind,
+		"description": s.description,
+	}
+	if version, ok := currentSchemaVersions[s.kind]; ok {
+		doc["description"] = fmt.Sprintf("%s (schema version %d)", s.description, version)
+	}
+	for key, value := range root {
+		doc[key] = value
+	}
+	if len(g.defs) > 0 {
+		doc["$defs"] = g.defs
+	}
+	return doc
+}
+
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return map[string]interface{}{"anyOf": []interface{}{g.schemaFor(t.Elem()), map[string]interface{}{"type": "null"}}}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": []interface{}{"array", "null"}, "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		schema := map[string]interface{}{"type": []interface{}{"object", "null"}, "additionalProperties": g.schemaFor(t.Elem())}
+		switch t.Key().Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			schema["propertyNames"] = map[string]interface{}{"pattern": "^-?[0-9]+$"}
+		}
+		return schema
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, done := g.defs[t.Name()]; !done {
+			g.defs[t.Name()] = map[string]interface{}{} // placeholder for recursive types
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes a struct's JSON encoding, following encoding/json's
+// field naming and omitempty rules
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []interface{}{}
+	additional := false
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Type == rawFieldsType {
+			// Undeclared API fields are kept and written back out
+			additional = true
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schemaFor(field.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": additional,
+	}
+}
+
+// marshalSchema renders a schema document the way it is written to disk
+func marshalSchema(doc map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// compileOutputSchemas compiles every generated schema, keyed by kind
+func compileOutputSchemas() (map[string]*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+	for _, s := range outputSchemas {
+		data, err := marshalSchema(generateJSONSchema(s))
+		if err != nil {
+			return nil, fmt.Errorf("encode %s schema: %w", s.kind, err)
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decode %s schema: %w", s.kind, err)
+		}
+		if err := compiler.AddResource(s.kind+".schema.json", doc); err != nil {
+			return nil, fmt.Errorf("add %s schema: %w", s.kind, err)
+		}
+	}
+
+	compiled := make(map[string]*jsonschema.Schema, len(outputSchemas))
+	for _, s := range outputSchemas {
+		schema, err := compiler.Compile(s.kind + ".schema.json")
+		if err != nil {
+			return nil, fmt.Errorf("compile %s schema: %w", s.kind, err)
+		}
+		compiled[s.kind] = schema
+	}
+	return compiled, nil
+}
+
+// schemaKindForFile returns the schema kind for a data file name, or "" when the
+// file has no generated schema. Tournament IDs may contain dashes, so the longest
+// matching kind wins ("archetype-decks" over a hypothetical "decks").
+func schemaKindForFile(name string) string {
+	match := ""
+	for _, s := range outputSchemas {
+		if name == s.kind+".json" || strings.HasPrefix(name, "tournament-") && strings.HasSuffix(name, "-"+s.kind+".json") {
+			if len(s.kind) > len(match) {
+				match = s.kind
+			}
+		}
+	}
+	return match
+}
+
+// validateDataFile checks one file against its compiled schema
+func validateDataFile(path string, schema *jsonschema.Schema) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	instance, err := jsonschema.UnmarshalJSON(file)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+	return schema.Validate(instance)
+}
+
+// runSchema implements the schema command
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	outputFlag := fs.String("output", filepath.Join(outputDir, schemaDir), "Directory to write the {kind}.schema.json files to")
+	fs.Parse(args)
+
+	if err := os.MkdirAll(*outputFlag, 0755); err != nil {
+		return fmt.Errorf("create schema directory: %w", err)
+	}
+	for _, s := range outputSchemas {
+		if err := writeJSON(filepath.Join(*outputFlag, s.kind+".schema.json"), generateJSONSchema(s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runValidate implements the validate command
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	dirFlag := fs.String("dir", outputDir, "Data directory to validate")
+	fs.Parse(args)
+
+	schemas, err := compileOutputSchemas()
+	if err != nil {
+		return err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(*dirFlag, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	checked, failed := 0, 0
+	for _, path := range paths {
+		kind := schemaKindForFile(filepath.Base(path))
+		if kind == "" {
+			log.Printf("  %s: no schema, skipped", filepath.Base(path))
+			continue
+		}
+		checked++
+		if err := validateDataFile(path, schemas[kind]); err != nil {
+			failed++
+			log.Printf("  ✗ %s: %v", filepath.Base(path), err)
+			continue
+		}
+		log.Printf("  ✓ %s", filepath.Base(path))
+	}
+
+	log.Printf("%d files checked, %d invalid", checked, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d files do not match their schema", failed)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// The committed schemas are the contract for the MCP server and web loaders, so
+// they must be regenerated (go run . schema) whenever a Go type changes
+func TestJSONSchemas_MatchCommittedFiles(t *testing.T) {
+	for _, s := range outputSchemas {
+		want, err := marshalSchema(generateJSONSchema(s))
+		if err != nil {
+			t.Fatalf("%s: %v", s.kind, err)
+		}
+		path := filepath.Join(outputDir, schemaDir, s.kind+".schema.json")
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", s.kind, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date; run `go run . schema`", path)
+		}
+	}
+}
+
+func TestGenerateJSONSchema_Struct(t *testing.T) {
+	doc := generateJSONSchema(outputSchema{kind: "decklists", description: "Decklists", goType: reflect.TypeOf([]DeckInfo{})})
+
+	if doc["type"] == nil || doc["items"].(map[string]interface{})["$ref"] != "#/$defs/DeckInfo" {
+		t.Fatalf("expected an array of DeckInfo refs, got %v", doc)
+	}
+	if !strings.Contains(doc["description"].(string), "schema version 2") {
+		t.Errorf("expected the schema version in the description, got %q", doc["description"])
+	}
+
+	deck := doc["$defs"].(map[string]interface{})["DeckInfo"].(map[string]interface{})
+	required := deck["required"].([]interface{})
+	has := func(name string) bool {
+		for _, r := range required {
+			if r == name {
+				return true
+			}
+		}
+		return false
+	}
+	if !has("playerName") || !has("mainDeck") {
+		t.Errorf("expected playerName and mainDeck to be required, got %v", required)
+	}
+	if has("decklistId") || has("similar") {
+		t.Errorf("omitempty fields must not be required, got %v", required)
+	}
+	if deck["additionalProperties"] != false {
+		t.Errorf("expected additionalProperties false")
+	}
+}
+
+func TestValidate_Fixtures(t *testing.T) {
+	schemas, err := compileOutputSchemas()
+	if err != nil {
+		t.Fatalf("compileOutputSchemas returned error: %v", err)
+	}
+
+	for _, name := range []string{"decklists-v1", "decklists-v2", "matches-v1", "matches-v2", "report-v1"} {
+		kind, _, _ := strings.Cut(name, "-v")
+		path := filepath.Join("testdata", "schema", name+".json")
+		if err := validateDataFile(path, schemas[kind]); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func TestValidate_RejectsDrift(t *testing.T) {
+	schemas, err := compileOutputSchemas()
+	if err != nil {
+		t.Fatalf("compileOutputSchemas returned error: %v", err)
+	}
+
+	cases := map[string]string{
+		"missing field": `[{"playerName": "A", "archetype": "Mono Red"}]`,
+		"unknown field": `[{"playerName": "A", "archetype": "Mono Red", "mainDeck": [], "sideboard": [], "deckUrl": ""}]`,
+		"wrong type":    `[{"playerName": "A", "archetype": "Mono Red", "mainDeck": [{"quantity": "4", "name": "Shock"}], "sideboard": null}]`,
+		"not an array":  `{"playerName": "A"}`,
+	}
+	for name, doc := range cases {
+		instance, err := jsonschema.UnmarshalJSON(strings.NewReader(doc))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if schemas["decklists"].Validate(instance) == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+
+	deck := []DeckInfo{{PlayerName: "A", Archetype: "Mono Red", MainDeck: []CardInfo{{Quantity: 4, Name: "Shock"}}}}
+	data, err := json.Marshal(deck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schemas["decklists"].Validate(instance); err != nil {
+		t.Errorf("encoded DeckInfo should validate: %v", err)
+	}
+}
+
+func TestSchemaKindForFile(t *testing.T) {
+	tests := map[string]string{
+		"tournaments.json":                                   "tournaments",
+		"tournament-394299-matches.json":                     "matches",
+		"tournament-415628-player-decks.json":                "player-decks",
+		"tournament-side-event-2026-05-archetype-decks.json": "archetype-decks",
+		"tournament-415628-duplicates.json":                  "duplicates",
+		"duplicates.json":                                    "duplicates",
+		"archetype-rules.json":                               "",
+	}
+	for name, want := range tests {
+		if got := schemaKindForFile(name); got != want {
+			t.Errorf("schemaKindForFile(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -2,24 +2,55 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
-)
 
-const (
-	outputDir    = "../data"
-	registryFile = "tournaments.json"
+	"github.com/sp3c1/protour-data-viz/scraper/carddata"
 )
 
+// outputDir holds the registry and every data file. It defaults to the repo's
+// data directory and is set with -output-dir or SCRAPER_OUTPUT_DIR.
+var outputDir = "../data"
+
+const registryFile = "tournaments.json"
+
 func main() {
+	if dir := os.Getenv("SCRAPER_OUTPUT_DIR"); dir != "" {
+		outputDir = dir
+	}
+
+	// A leading non-flag argument selects a subcommand; otherwise we scrape.
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	tournamentFlag := flag.String("tournament", "", "Tournament ID to scrape (must exist in registry). If empty, scrapes all non-completed tournaments.")
 	roundsFlag := flag.String("rounds", "", "Override rounds for this run (e.g. '4-8' or '4-8,12-16'). When empty, uses the registry's rounds field.")
+	cardDataFlag := flag.String("carddata", "", "Local Scryfall default-cards or MTGJSON AtomicCards file. When set, decklist cards are enriched with oracle metadata.")
+	refreshDecklistsFlag := flag.Bool("refresh-decklists", false, "Download every decklist again instead of reusing saved ones by decklist ID, to pick up lists edited under the same ID")
+	flag.StringVar(&outputDir, "output-dir", outputDir, "Directory holding the registry and data files")
+	s3 := addS3Flags(flag.CommandLine)
 	flag.Parse()
 
+	sink, err := s3.sink()
+	if err != nil {
+		log.Fatalf("Invalid S3 output: %v", err)
+	}
+	if sink != nil {
+		uploadSinks = append(uploadSinks, sink)
+		log.Printf("Uploading output files to %s", sink)
+	}
+
 	registryPath := filepath.Join(outputDir, registryFile)
 	registry, err := loadRegistry(registryPath)
 	if err != nil {
@@ -48,22 +79,46 @@ func main() {
 		log.Fatalf("Failed to create output directory: %v", err)
 	}
 
+	var cards *carddata.DB
+	if *cardDataFlag != "" {
+		log.Printf("Loading card data from %s...", *cardDataFlag)
+		cards, err = carddata.Load(*cardDataFlag)
+		if err != nil {
+			log.Fatalf("Failed to load card data: %v", err)
+		}
+		log.Printf("Loaded %d cards", cards.Len())
+	}
+
 	for i, t := range targets {
 		if i > 0 {
 			time.Sleep(1 * time.Second) // polite delay between tournaments
 		}
-		if err := scrapeTournament(t, *roundsFlag); err != nil {
+		if err := scrapeTournament(t, *roundsFlag, cards, *refreshDecklistsFlag); err != nil {
 			log.Printf("Tournament %s (%s) failed: %v", t.ID, t.Name, err)
 			continue
 		}
 	}
 
+	if len(uploadSinks) > 0 {
+		topLevel, err := existingTopLevelDataFiles()
+		if err != nil {
+			log.Fatalf("Failed to list data files to upload: %v", err)
+		}
+		for _, sink := range uploadSinks {
+			if err := uploadFiles(sink, topLevel); err != nil {
+				log.Printf("Upload failed: %v", err)
+			}
+		}
+	}
+
 	log.Println("Scraping completed.")
 }
 
 // scrapeTournament runs the full scrape for one tournament.
 // roundsOverride, when non-empty, replaces the registry's rounds for this run only.
-func scrapeTournament(t Tournament, roundsOverride string) error {
+// cards, when non-nil, is used to enrich decklists with card metadata.
+// refreshDecklists downloads every decklist instead of reusing saved ones.
+func scrapeTournament(t Tournament, roundsOverride string, cards *carddata.DB, refreshDecklists bool) error {
 	tournamentURL := fmt.Sprintf("https://melee.gg/Tournament/View/%s", t.ID)
 	log.Printf("Starting scrape of %s (%s)", t.ID, t.Name)
 	log.Printf("  URL: %s", tournamentURL)
@@ -82,6 +137,15 @@ func scrapeTournament(t Tournament, roundsOverride string) error {
 	}
 	log.Printf("  Resolved round numbers: %v", rounds)
 
+	report := &RunReport{
+		TournamentID: t.ID,
+		StartedAt:    time.Now().UTC(),
+		Rounds:       rounds,
+		FailedRounds: []int{},
+	}
+	// rows records each file this run saves, for the manifest
+	rows := make(map[string]int)
+
 	log.Println("  Discovering melee.gg round IDs...")
 	roundIDs, err := fetchRoundIDs(t.ID)
 	if err != nil {
@@ -96,10 +160,12 @@ func scrapeTournament(t Tournament, roundsOverride string) error {
 		matches, err := fetchRoundMatches(t.ID, roundIDs, roundNum)
 		if err != nil {
 			log.Printf("  Warning: failed to fetch Round %d: %v", roundNum, err)
+			report.FailedRounds = append(report.FailedRounds, roundNum)
 			continue
 		}
 		log.Printf("    %d matches", matches.RecordsTotal)
 		allMatches[roundNum] = matches.Data
+		report.Matches += len(matches.Data)
 
 		time.Sleep(1 * time.Second)
 	}
@@ -107,26 +173,140 @@ func scrapeTournament(t Tournament, roundsOverride string) error {
 	if err := saveMatchData(t.ID, allMatches); err != nil {
 		return fmt.Errorf("save matches: %w", err)
 	}
+	rows["matches"] = report.Matches
+
+	report.SchemaDrift, err = recordMatchFields(filepath.Join(outputDir, matchFieldsFile), t.ID, extraMatchFields(allMatches), report.StartedAt)
+	if err != nil {
+		return fmt.Errorf("record match fields: %w", err)
+	}
+	for _, field := range report.SchemaDrift {
+		log.Printf("  Schema drift: melee.gg returned a new match field %s", field)
+	}
 
 	log.Println("  Extracting deck info from matches...")
 	playerArchetype := extractPlayerDecksFromMatches(allMatches)
 	playerNames := extractPlayerNamesFromMatches(allMatches)
 	log.Printf("  %d players mapped to decks", len(playerArchetype))
 
+	previous, err := loadDecklists(t.ID)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("load previous decklists: %w", err)
+	}
+
+	aliasesPath := filepath.Join(outputDir, archetypeAliasesFile)
+	aliases, err := loadArchetypeAliases(aliasesPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		log.Printf("  No %s found, keeping archetype labels as entered", aliasesPath)
+	case err != nil:
+		return fmt.Errorf("load archetype aliases: %w", err)
+	default:
+		// Overrides may label players without an archetype, including ones only
+		// known from imported decklists
+		players := make(map[string]string, len(playerNames))
+		for name, display := range playerNames {
+			players[name] = display
+		}
+		for _, deck := range previous {
+			players[normalizePlayerName(deck.PlayerName)] = deck.PlayerName
+		}
+		changes := aliases.apply(t.ID, playerArchetype, players)
+		for _, c := range changes {
+			log.Printf("    %s: %q -> %q (%s)", c.Player, c.From, c.To, c.Source)
+		}
+		log.Printf("  Applied %d archetype aliases/overrides", len(changes))
+
+		if err := saveArchetypeChanges(t.ID, changes); err != nil {
+			return fmt.Errorf("save archetype changes: %w", err)
+		}
+		rows["archetype-changes"] = len(changes)
+	}
+
 	if err := savePlayerDeckMapping(t.ID, playerArchetype); err != nil {
 		return fmt.Errorf("save player decks: %w", err)
 	}
+	rows["player-decks"] = len(playerArchetype)
+
+	cached := decklistsByID(previous)
+	if refreshDecklists {
+		log.Printf("  Ignoring %d saved decklists (-refresh-decklists)", len(cached))
+		cached = nil
+	}
 
 	log.Println("  Fetching complete decklists from melee.gg...")
-	decklists, err := fetchDecklistsFromMelee(allMatches, playerArchetype, playerNames)
+	decklists, err := fetchDecklistsFromMelee(allMatches, playerArchetype, playerNames, cached)
 	if err != nil {
 		return fmt.Errorf("fetch decklists: %w", err)
 	}
-	log.Printf("  Fetched %d decklists", len(decklists))
+	for _, deck := range decklists {
+		if _, ok := cached[deck.DecklistID]; ok {
+			report.CachedDecklists++
+		}
+	}
+	log.Printf("  Fetched %d decklists (%d reused by decklist ID from the last scrape)", len(decklists), report.CachedDecklists)
+	report.Decklists = len(decklists)
+	hashDecklists(decklists)
+
+	if cards != nil {
+		log.Println("  Enriching decklists with card data...")
+		if err := enrichDecklists(decklists, cards); err != nil {
+			return fmt.Errorf("enrich decklists: %w", err)
+		}
+	}
+
+	rulesPath := filepath.Join(outputDir, archetypeRulesFile)
+	rules, err := loadArchetypeRules(rulesPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		log.Printf("  No %s found, skipping archetype classification", rulesPath)
+	case err != nil:
+		return fmt.Errorf("load archetype rules: %w", err)
+	default:
+		log.Printf("  Classifying decklists with rules v%d...", rules.Version)
+		classification := classifyDecklists(decklists, rules)
+		log.Printf("  %d/%d decks classified, %d disagree with their label", classification.Classified, classification.Decks, len(classification.Disagreements))
+
+		if err := saveClassificationReport(t.ID, classification); err != nil {
+			return fmt.Errorf("save classification: %w", err)
+		}
+		rows["classification"] = classification.Decks
+	}
+
+	legalityPath := filepath.Join(outputDir, legalityFile)
+	var legality FormatLegality
+	legalityRules, err := loadLegalityRules(legalityPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		log.Printf("  No %s found, checking deck construction rules only", legalityPath)
+	case err != nil:
+		return fmt.Errorf("load legality: %w", err)
+	default:
+		var ok bool
+		if legality, ok = legalityRules.forFormat(t.Format); !ok {
+			log.Printf("  No %s entry for format %q, checking deck construction rules only", legalityPath, t.Format)
+		}
+	}
+
+	log.Println("  Validating decklists...")
+	report.Validation = validateDecklists(decklists, t.Format, legality, cards)
+	log.Printf("  %d/%d decklists valid", report.Validation.ValidDecks, report.Validation.Decks)
+	for _, invalid := range report.Validation.InvalidDecks {
+		for _, violation := range invalid.Violations {
+			log.Printf("    %s: %s", invalid.PlayerName, violation.Message)
+		}
+	}
+
+	addSimilarDecks(decklists, similarDecksPerDeck)
+
+	report.IdenticalDecks = identicalDecks(decklists, make([]string, len(decklists)))
+	for _, g := range report.IdenticalDecks {
+		log.Printf("  %d players registered the exact same %s list", len(g.Decks), describeArchetypes(g.Decks))
+	}
 
 	if err := saveDecklistsData(t.ID, decklists); err != nil {
 		return fmt.Errorf("save decklists: %w", err)
 	}
+	rows["decklists"] = len(decklists)
 
 	if len(playerArchetype) > 0 && len(allMatches) > 0 {
 		log.Println("  Aggregating statistics...")
@@ -136,10 +316,72 @@ func scrapeTournament(t Tournament, roundsOverride string) error {
 		if err := saveStatsData(t.ID, stats); err != nil {
 			return fmt.Errorf("save stats: %w", err)
 		}
+		rows["stats"] = len(stats.Archetypes)
 
 		printStatsSummary(stats)
 	}
 
+	if len(decklists) > 0 {
+		log.Println("  Building archetype deck profiles...")
+		archetypeDecks := aggregateArchetypeDecks(decklists)
+		log.Printf("  Profiles for %d archetypes", len(archetypeDecks.Archetypes))
+
+		if err := saveArchetypeDecksData(t.ID, archetypeDecks); err != nil {
+			return fmt.Errorf("save archetype decks: %w", err)
+		}
+		rows["archetype-decks"] = len(archetypeDecks.Archetypes)
+	}
+
+	if len(decklists) > 0 && len(allMatches) > 0 {
+		log.Println("  Aggregating card statistics...")
+		cardStats := aggregateCardStats(allMatches, decklists)
+		log.Printf("  Stats for %d cards across %d decks", len(cardStats.Cards), cardStats.TotalDecks)
+
+		if err := saveCardStatsData(t.ID, cardStats); err != nil {
+			return fmt.Errorf("save card stats: %w", err)
+		}
+		rows["cards"] = len(cardStats.Cards)
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	if err := saveRunReport(t.ID, report); err != nil {
+		return fmt.Errorf("save run report: %w", err)
+	}
+	rows["report"] = 1
+
+	manifest, err := buildOutputManifest(t.ID, report.StartedAt, rows)
+	if err != nil {
+		return fmt.Errorf("build manifest: %w", err)
+	}
+	if err := saveOutputManifest(t.ID, manifest); err != nil {
+		return fmt.Errorf("save manifest: %w", err)
+	}
+
+	snapshotID, saved, err := tournamentHistory(t.ID).save(manifest, func(kind string) string { return dataFilePath(t.ID, kind) })
+	switch {
+	case err != nil:
+		return fmt.Errorf("save history snapshot: %w", err)
+	case saved:
+		log.Printf("  Saved history snapshot %s", snapshotID)
+	default:
+		log.Printf("  Data unchanged since history snapshot %s", snapshotID)
+	}
+
+	// Every file is written locally by now; uploads only ever copy complete data
+	if len(uploadSinks) > 0 {
+		names := []string{filepath.Base(dataFilePath(t.ID, "manifest"))}
+		for _, file := range manifest.Files {
+			names = append(names, file.File)
+		}
+		sort.Strings(names)
+		for _, sink := range uploadSinks {
+			log.Printf("  Uploading %d files to %s...", len(names), sink)
+			if err := uploadFiles(sink, names); err != nil {
+				return fmt.Errorf("upload (local files are complete): %w", err)
+			}
+		}
+	}
+
 	log.Printf("Tournament %s done.", t.ID)
 	return nil
 }
@@ -200,26 +442,98 @@ func saveStatsData(tournamentID string, stats *TournamentStats) error {
 	return saveJSON(tournamentID, "stats", stats)
 }
 
+func saveCardStatsData(tournamentID string, cardStats *TournamentCardStats) error {
+	return saveJSON(tournamentID, "cards", cardStats)
+}
+
+func saveArchetypeDecksData(tournamentID string, archetypeDecks *TournamentArchetypeDecks) error {
+	return saveJSON(tournamentID, "archetype-decks", archetypeDecks)
+}
+
+func saveClassificationReport(tournamentID string, report *ClassificationReport) error {
+	return saveJSON(tournamentID, "classification", report)
+}
+
+func saveArchetypeChanges(tournamentID string, changes []ArchetypeChange) error {
+	return saveJSON(tournamentID, "archetype-changes", changes)
+}
+
+func saveRunReport(tournamentID string, report *RunReport) error {
+	return saveJSON(tournamentID, "report", report)
+}
+
+func saveOutputManifest(tournamentID string, manifest *OutputManifest) error {
+	return saveJSON(tournamentID, "manifest", manifest)
+}
+
+// saveJSON writes one of a tournament's output files to outputDir and any extra sinks
 func saveJSON(tournamentID, kind string, data interface{}) error {
-	filename := fmt.Sprintf("tournament-%s-%s.json", tournamentID, kind)
-	outputPath := filepath.Join(outputDir, filename)
+	return saveOutputFile(filepath.Base(dataFilePath(tournamentID, kind)), data)
+}
+
+// dataFilePath returns the path of one of a tournament's output files, e.g. kind "matches"
+func dataFilePath(tournamentID, kind string) string {
+	return filepath.Join(outputDir, fmt.Sprintf("tournament-%s-%s.json", tournamentID, kind))
+}
+
+// writeJSON writes data as indented JSON to outputPath, atomically (see writeFileAtomic)
+func writeJSON(outputPath string, data interface{}) error {
+	body, err := encodeJSON(data)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", outputPath, err)
+	}
+	return writeFileAtomic(outputPath, func(w io.Writer) error {
+		_, err := w.Write(body)
+		return err
+	})
+}
 
-	file, err := os.Create(outputPath)
+// writeFileAtomic writes outputPath with write. The data goes to a temp file in the
+// same directory that is renamed into place, so readers never see a partly
+// written file.
+func writeFileAtomic(outputPath string, write func(w io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".tmp-*")
 	if err != nil {
-		return fmt.Errorf("create %s: %w", outputPath, err)
+		return fmt.Errorf("create temp file for %s: %w", outputPath, err)
 	}
-	defer file.Close()
+	tempPath := file.Name()
+	defer os.Remove(tempPath)
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
+	if err := write(file); err != nil {
+		file.Close()
 		return fmt.Errorf("encode %s: %w", outputPath, err)
 	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("sync %s: %w", tempPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", tempPath, err)
+	}
+	if err := os.Chmod(tempPath, 0644); err != nil {
+		return fmt.Errorf("chmod %s: %w", tempPath, err)
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		return fmt.Errorf("rename %s: %w", tempPath, err)
+	}
 
 	log.Printf("    Saved %s", outputPath)
 	return nil
 }
 
+// loadJSON reads one of a tournament's previously saved output files into v
+func loadJSON(tournamentID, kind string, v interface{}) error {
+	path := dataFilePath(tournamentID, kind)
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(bytes, v); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
 func extractPlayerDecksFromMatches(allMatches map[int][]Match) map[string]string {
 	playerDecks := make(map[string]string)
 
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+)
+
+// scraperVersion can be set at build time with -ldflags "-X main.scraperVersion=v1.2.0".
+// When empty, the VCS revision stamped by go build is used.
+var scraperVersion string
+
+// ManifestFile describes one output file written by a scrape
+type ManifestFile struct {
+	File          string `json:"file"`
+	SchemaVersion int    `json:"schemaVersion"`
+	SHA256        string `json:"sha256"`
+	Bytes         int64  `json:"bytes"`
+	Rows          int    `json:"rows"`
+}
+
+// OutputManifest lists the files one scrape wrote for a tournament, keyed by kind
+// ("matches", "decklists", ...). Files missing from it were not written by the
+// scrape that produced it, so consumers should not mix them with the listed ones.
+// ScrapedAt is zero for manifests inferred from files scraped before manifests existed.
+type OutputManifest struct {
+	SchemaVersion  int                     `json:"schemaVersion"`
+	TournamentID   string                  `json:"tournamentId"`
+	ScraperVersion string                  `json:"scraperVersion"`
+	ScrapedAt      time.Time               `json:"scrapedAt"`
+	MigratedAt     *time.Time              `json:"migratedAt,omitempty"`
+	Files          map[string]ManifestFile `json:"files"`
+}
+
+// buildOutputManifest hashes the saved file of each kind in rows. rows maps a kind
+// to its row count: matches, decks, archetypes or cards, depending on the file.
+func buildOutputManifest(tournamentID string, scrapedAt time.Time, rows map[string]int) (*OutputManifest, error) {
+	manifest := &OutputManifest{
+		SchemaVersion:  manifestSchemaVersion,
+		TournamentID:   tournamentID,
+		ScraperVersion: buildVersion(),
+		ScrapedAt:      scrapedAt,
+		Files:          make(map[string]ManifestFile, len(rows)),
+	}
+	for kind, count := range rows {
+		if err := manifest.record(kind, count); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// record hashes the saved file of kind and lists it at the current schema version
+func (m *OutputManifest) record(kind string, rows int) error {
+	path := dataFilePath(m.TournamentID, kind)
+	sum, size, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	m.Files[kind] = ManifestFile{
+		File:          filepath.Base(path),
+		SchemaVersion: currentSchemaVersions[kind],
+		SHA256:        sum,
+		Bytes:         size,
+		Rows:          rows,
+	}
+	return nil
+}
+
+// fileSHA256 returns the hex SHA-256 and size of a file
+func fileSHA256(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// buildVersion returns scraperVersion, else the VCS revision from the build info
+// (with a "-dirty" suffix for uncommitted changes), else "dev"
+func buildVersion() string {
+	if scraperVersion != "" {
+		return scraperVersion
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	revision, modified := "", false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "dev"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified {
+		revision += "-dirty"
+	}
+	return revision
+}
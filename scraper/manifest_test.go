@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteJSON_ReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tournament-1-stats.json")
+	if err := os.WriteFile(path, []byte(`{"old": true}`), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	if err := writeJSON(path, map[string]int{"decks": 2}); err != nil {
+		t.Fatalf("writeJSON returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(data) != "{\n  \"decks\": 2\n}\n" {
+		t.Errorf("unexpected contents: %q", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("expected mode 0644, got %v", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp files should not be left behind: %v", entries)
+	}
+}
+
+func TestWriteJSON_FailureKeepsOldFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tournament-1-stats.json")
+	if err := os.WriteFile(path, []byte(`{"old": true}`), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	if err := writeJSON(path, map[string]interface{}{"bad": make(chan int)}); err == nil {
+		t.Fatal("expected an encoding error")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != `{"old": true}` {
+		t.Errorf("failed write should leave the old file untouched: %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temp files should not be left behind: %v", entries)
+	}
+}
+
+func TestFileSHA256(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.json")
+	contents := []byte("[1, 2, 3]\n")
+	if err := os.WriteFile(path, contents, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	sum, size, err := fileSHA256(path)
+	want := sha256.Sum256(contents)
+	if err != nil || sum != hex.EncodeToString(want[:]) || size != int64(len(contents)) {
+		t.Errorf("unexpected hash %s (%d bytes), err %v", sum, size, err)
+	}
+	if _, _, err := fileSHA256(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestBuildVersion(t *testing.T) {
+	defer func(v string) { scraperVersion = v }(scraperVersion)
+
+	scraperVersion = "v1.2.0"
+	if got := buildVersion(); got != "v1.2.0" {
+		t.Errorf("expected the ldflags version, got %q", got)
+	}
+	scraperVersion = ""
+	if got := buildVersion(); got == "" {
+		t.Error("build version should never be empty")
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// defaultStreamInterval is how often stream -watch rereads the matches files
+const defaultStreamInterval = time.Minute
+
+// MatchEvent is one line of the NDJSON match stream. Event is "result" the first
+// time a match is reported and "correction" when its result string changes
+// later. Kind is "win", "draw" or "bye"; Player2 is empty for byes, Winner for
+// draws, and the game counts are from each player's side.
+type MatchEvent struct {
+	Event            string    `json:"event"`
+	TournamentID     string    `json:"tournamentId"`
+	Round            int       `json:"round"`
+	Table            int       `json:"table"`
+	Player1          string    `json:"player1"`
+	Player1Archetype string    `json:"player1Archetype"`
+	Player2          string    `json:"player2"`
+	Player2Archetype string    `json:"player2Archetype"`
+	Kind             string    `json:"kind"`
+	Winner           string    `json:"winner"`
+	Player1Games     int       `json:"player1Games"`
+	Player2Games     int       `json:"player2Games"`
+	DrawGames        int       `json:"drawGames"`
+	Result           string    `json:"result"`
+	EmittedAt        time.Time `json:"emittedAt"`
+}
+
+// key identifies a match across rescrapes. Table numbers can be reassigned, so
+// it uses the tournament, round and both players regardless of order.
+func (e MatchEvent) key() string {
+	players := []string{normalizePlayerName(e.Player1), normalizePlayerName(e.Player2)}
+	sort.Strings(players)
+	return fmt.Sprintf("%s|%d|%s|%s", e.TournamentID, e.Round, players[0], players[1])
+}
+
+// matchEvents lists a tournament's reported results in round and table order.
+// Matches without a result string yet are left out.
+func matchEvents(tournamentID string, allMatches map[int][]Match, playerArchetype map[string]string) []MatchEvent {
+	var events []MatchEvent
+	for _, row := range matchRows(tournamentID, allMatches, playerArchetype) {
+		if row.Result == "" {
+			continue
+		}
+		event := MatchEvent{
+			TournamentID:     row.TournamentID,
+			Round:            row.Round,
+			Table:            row.TableNumber,
+			Player1:          row.Player1,
+			Player1Archetype: row.Player1Archetype,
+			Player2:          row.Player2,
+			Player2Archetype: row.Player2Archetype,
+			Winner:           row.Winner,
+			Player1Games:     row.Player1Games,
+			Player2Games:     row.Player2Games,
+			DrawGames:        row.DrawGames,
+			Result:           row.Result,
+		}
+		switch {
+		case row.Player2 == "":
+			event.Kind = "bye"
+		case row.Winner == "":
+			event.Kind = "draw"
+		default:
+			event.Kind = "win"
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// matchStream appends match events to w, skipping matches whose result was
+// already emitted unchanged
+type matchStream struct {
+	w    io.Writer
+	seen map[string]string
+	now  func() time.Time
+}
+
+func newMatchStream(w io.Writer) *matchStream {
+	return &matchStream{w: w, seen: make(map[string]string), now: func() time.Time { return time.Now().UTC() }}
+}
+
+// resume marks the events in a previously written stream as emitted, so appending
+// to an existing file continues it instead of repeating it
+func (s *matchStream) resume(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event MatchEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// A line cut off by a crash; the match is emitted again
+			continue
+		}
+		s.seen[event.key()] = event.Result
+	}
+	return scanner.Err()
+}
+
+// emit writes the new and corrected events, one JSON object per line, and returns
+// how many it wrote
+func (s *matchStream) emit(events []MatchEvent) (int, error) {
+	written := 0
+	for _, event := range events {
+		key := event.key()
+		previous, seen := s.seen[key]
+		if seen && previous == event.Result {
+			continue
+		}
+		event.Event = "result"
+		if seen {
+			event.Event = "correction"
+		}
+		event.EmittedAt = s.now()
+
+		line, err := json.Marshal(event)
+		if err != nil {
+			return written, err
+		}
+		// One write per line, so a reader tailing the file never sees half an event
+		if _, err := s.w.Write(append(line, '\n')); err != nil {
+			return written, err
+		}
+		s.seen[key] = event.Result
+		written++
+	}
+	return written, nil
+}
+
+// terminateLastLine ends a file cut off mid-line by a crash with a newline, so the
+// next event starts on a line of its own
+func terminateLastLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	_, err = file.Write([]byte{'\n'})
+	return err
+}
+
+// loadStreamEvents reads the current results of the given tournaments, or every
+// registry tournament when tournamentID is empty
+func loadStreamEvents(tournamentID string) ([]MatchEvent, error) {
+	tournamentIDs := []string{tournamentID}
+	if tournamentID == "" {
+		registry, err := loadRegistry(filepath.Join(outputDir, registryFile))
+		if err != nil {
+			return nil, err
+		}
+		tournamentIDs = nil
+		for _, t := range registry {
+			tournamentIDs = append(tournamentIDs, t.ID)
+		}
+	}
+
+	var events []MatchEvent
+	for _, id := range tournamentIDs {
+		allMatches, err := loadMatches(id)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var playerArchetype map[string]string
+		if err := loadJSON(id, "player-decks", &playerArchetype); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		events = append(events, matchEvents(id, allMatches, playerArchetype)...)
+	}
+	return events, nil
+}
+
+// runStream implements the stream command
+func runStream(args []string) error {
+	fs := flag.NewFlagSet("stream", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Only stream this tournament. Defaults to every tournament in the registry.")
+	outputFlag := fs.String("output", "-", "NDJSON file to append to, or - for stdout")
+	watchFlag := fs.Bool("watch", false, "Keep rereading the matches files and append new or corrected results")
+	intervalFlag := fs.Duration("interval", defaultStreamInterval, "How often -watch rereads the matches files")
+	fs.Parse(args)
+
+	var stream *matchStream
+	if *outputFlag == "-" {
+		stream = newMatchStream(os.Stdout)
+	} else {
+		file, err := os.OpenFile(*outputFlag, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", *outputFlag, err)
+		}
+		defer file.Close()
+		stream = newMatchStream(file)
+		if err := stream.resume(file); err != nil {
+			return fmt.Errorf("read %s: %w", *outputFlag, err)
+		}
+		if err := terminateLastLine(file); err != nil {
+			return fmt.Errorf("write %s: %w", *outputFlag, err)
+		}
+	}
+
+	for {
+		events, err := loadStreamEvents(*tournamentFlag)
+		if err != nil {
+			if !*watchFlag {
+				return err
+			}
+			// A read error shouldn't end the feed; try again next time
+			log.Printf("  Warning: %v", err)
+			time.Sleep(*intervalFlag)
+			continue
+		}
+		written, err := stream.emit(events)
+		if err != nil {
+			return fmt.Errorf("write %s: %w", *outputFlag, err)
+		}
+		if written > 0 || !*watchFlag {
+			log.Printf("Streamed %d match results", written)
+		}
+		if !*watchFlag {
+			return nil
+		}
+		time.Sleep(*intervalFlag)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeEvents(t *testing.T, data string) []MatchEvent {
+	t.Helper()
+	var events []MatchEvent
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		if line == "" {
+			continue
+		}
+		var event MatchEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestMatchEvents_Kinds(t *testing.T) {
+	matches := map[int][]Match{
+		4: {
+			testMatch(t, "Alice", "Bob", "Bob won 2-1-0"),
+			testMatch(t, "Carol", "Dave", "1-1-0 Draw"),
+			testMatch(t, "Erin", "", "Erin was assigned a bye"),
+			testMatch(t, "Frank", "Gina", ""),
+		},
+	}
+	events := matchEvents("1", matches, map[string]string{"alice": "Izzet Prowess", "bob": "Jeskai Control"})
+
+	if len(events) != 3 {
+		t.Fatalf("expected the unreported match to be skipped, got %+v", events)
+	}
+	kinds := []string{events[0].Kind, events[1].Kind, events[2].Kind}
+	if strings.Join(kinds, ",") != "win,draw,bye" {
+		t.Errorf("expected win, draw and bye, got %v", kinds)
+	}
+	if e := events[0]; e.Winner != "Bob" || e.Player1Games != 1 || e.Player2Games != 2 || e.Player2Archetype != "Jeskai Control" {
+		t.Errorf("unexpected win event: %+v", e)
+	}
+}
+
+func TestMatchStream_AppendsOnlyNewAndCorrected(t *testing.T) {
+	var out bytes.Buffer
+	stream := newMatchStream(&out)
+	stream.now = func() time.Time { return time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	round4 := []Match{testMatch(t, "Alice", "Bob", "Bob won 2-1-0")}
+	if n, err := stream.emit(matchEvents("1", map[int][]Match{4: round4}, nil)); err != nil || n != 1 {
+		t.Fatalf("first emit: wrote %d, err %v", n, err)
+	}
+
+	// Rescrape: round 4 unchanged apart from a new table number, round 5 is new
+	round4 = []Match{testMatch(t, "Bob", "Alice", "Bob won 2-1-0")}
+	round4[0].TableNumber = 7
+	round5 := []Match{testMatch(t, "Alice", "Carol", "Alice won 2-0-0")}
+	if n, err := stream.emit(matchEvents("1", map[int][]Match{4: round4, 5: round5}, nil)); err != nil || n != 1 {
+		t.Fatalf("second emit: wrote %d, err %v", n, err)
+	}
+
+	// The round 5 result is corrected
+	round5 = []Match{testMatch(t, "Alice", "Carol", "Carol won 2-1-0")}
+	if n, err := stream.emit(matchEvents("1", map[int][]Match{4: round4, 5: round5}, nil)); err != nil || n != 1 {
+		t.Fatalf("third emit: wrote %d, err %v", n, err)
+	}
+
+	events := decodeEvents(t, out.String())
+	if len(events) != 3 {
+		t.Fatalf("expected 3 lines, got %d:\n%s", len(events), out.String())
+	}
+	if events[1].Event != "result" || events[1].Round != 5 {
+		t.Errorf("expected the new round 5 result, got %+v", events[1])
+	}
+	if events[2].Event != "correction" || events[2].Winner != "Carol" {
+		t.Errorf("expected a correction won by Carol, got %+v", events[2])
+	}
+	if !events[0].EmittedAt.Equal(stream.now()) {
+		t.Errorf("expected emittedAt to be set, got %v", events[0].EmittedAt)
+	}
+}
+
+func TestMatchStream_Resume(t *testing.T) {
+	var previous bytes.Buffer
+	first := newMatchStream(&previous)
+	matches := map[int][]Match{4: {
+		testMatch(t, "Alice", "Bob", "Bob won 2-1-0"),
+		testMatch(t, "Carol", "Dave", "Carol won 2-0-0"),
+	}}
+	if _, err := first.emit(matchEvents("1", matches, nil)); err != nil {
+		t.Fatal(err)
+	}
+	// A crash cut the last line short
+	existing := previous.String()[:previous.Len()-10]
+
+	var out bytes.Buffer
+	resumed := newMatchStream(&out)
+	if err := resumed.resume(strings.NewReader(existing)); err != nil {
+		t.Fatalf("resume returned error: %v", err)
+	}
+	if _, err := resumed.emit(matchEvents("1", matches, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	events := decodeEvents(t, out.String())
+	if len(events) != 1 || events[0].Player1 != "Carol" || events[0].Event != "result" {
+		t.Errorf("expected only the cut-off match again, got %+v", events)
+	}
+}
@@ -9,33 +9,35 @@ import (
 "time"
 )
 
-// fetchDecklistsFromMelee fetches full decklists with card information from melee.gg
-func fetchDecklistsFromMelee(allMatches map[int][]Match, playerArchetype map[string]string, playerNames map[string]string) ([]DeckInfo, error) {
+// fetchDecklistsFromMelee fetches full decklists with card information from melee.gg.
+// Decklists found in cached (keyed by melee.gg decklist ID, see decklistsByID) are
+// reused instead of downloaded; pass nil to download every list.
+func fetchDecklistsFromMelee(allMatches map[int][]Match, playerArchetype map[string]string, playerNames map[string]string, cached map[string]DeckInfo) ([]DeckInfo, error) {
 // Build a map of player -> decklist ID (deduplicated)
-decklistIDs := make(map[string]string) // normalized name -> decklist ID
-
-for _, matches := range allMatches {
-for _, match := range matches {
-for _, competitor := range match.Competitors {
-if len(competitor.Decklists) > 0 && len(competitor.Team.Players) > 0 {
-decklistID := competitor.Decklists[0].DecklistID
-playerName := competitor.Team.Players[0].DisplayName
-normalizedName := normalizePlayerName(playerName)
-
-if decklistID != "" {
-decklistIDs[normalizedName] = decklistID
-}
-}
-}
-}
-}
+decklistIDs := decklistIDsFromMatches(allMatches) // normalized name -> decklist ID
 
 // Fetch each unique decklist
 var decklists []DeckInfo
 count := 0
-total := len(decklistIDs)
+total := 0
+for _, decklistID := range decklistIDs {
+if _, ok := cached[decklistID]; !ok {
+total++
+}
+}
 
 for normalizedName, decklistID := range decklistIDs {
+if deck, ok := cached[decklistID]; ok {
+decklists = append(decklists, DeckInfo{
+PlayerName: playerNames[normalizedName],
+Archetype:  playerArchetype[normalizedName],
+DecklistID: decklistID,
+MainDeck:   deck.MainDeck,
+Sideboard:  deck.Sideboard,
+})
+continue
+}
+
 count++
 if count%10 == 0 {
 fmt.Printf("    Fetching decklist %d/%d...\n", count, total)
@@ -94,6 +96,7 @@ mainDeck, sideboard := parseCardsFromMeleeHTML(html)
 return DeckInfo{
 PlayerName: playerName,
 Archetype:  archetype,
+DecklistID: decklistID,
 MainDeck:   mainDeck,
 Sideboard:  sideboard,
 }, nil
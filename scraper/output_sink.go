@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// outputSink stores output files. Names are slash-separated and relative to the
+// output root, e.g. "tournament-394299-matches.json".
+//
+// Output is always saved to a localSink on outputDir: later scrape steps, the
+// manifest, history and every other command read the files back from disk. Other
+// sinks, such as S3, are upload-only copies of that directory (see uploadFiles).
+type outputSink interface {
+	put(name string, body []byte) error
+	String() string
+}
+
+// localSink writes files under a directory, atomically (see writeFileAtomic)
+type localSink struct {
+	dir string
+}
+
+func (s localSink) put(name string, body []byte) error {
+	outputPath := filepath.Join(s.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(outputPath), err)
+	}
+	return writeFileAtomic(outputPath, func(w io.Writer) error {
+		_, err := w.Write(body)
+		return err
+	})
+}
+
+func (s localSink) String() string {
+	return s.dir
+}
+
+// uploadSinks receive copies of the output files, such as the S3 bucket set with
+// -s3. Files are always written to outputDir first, since later steps and commands
+// read them back from there, and uploaded once a tournament's scrape is complete
+// (see uploadFiles), so a failed upload never leaves the local data half written.
+var uploadSinks []outputSink
+
+// saveOutputFile writes data as indented JSON to outputDir
+func saveOutputFile(name string, data interface{}) error {
+	body, err := encodeJSON(data)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	sink := localSink{dir: outputDir}
+	if err := sink.put(name, body); err != nil {
+		return fmt.Errorf("%s: %w", sink, err)
+	}
+	return nil
+}
+
+// topLevelDataFiles are the output files outside any tournament, in upload order:
+// the registry last, since it says which tournaments there are
+var topLevelDataFiles = []string{matchFieldsFile, duplicatesFile, registryFile}
+
+// uploadOrder sorts file names so manifests come after the files they list, and
+// the registry after everything, so readers never see one naming a file not yet
+// uploaded. The order is otherwise kept.
+func uploadOrder(names []string) []string {
+	rank := func(name string) int {
+		switch {
+		case name == registryFile:
+			return 2
+		case strings.HasSuffix(name, "-manifest.json"):
+			return 1
+		}
+		return 0
+	}
+	ordered := append([]string(nil), names...)
+	sort.SliceStable(ordered, func(i, j int) bool { return rank(ordered[i]) < rank(ordered[j]) })
+	return ordered
+}
+
+// uploadFiles copies files from outputDir to sink in upload order, stopping at
+// the first failure
+func uploadFiles(sink outputSink, names []string) error {
+	for _, name := range uploadOrder(names) {
+		body, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		if err := sink.put(name, body); err != nil {
+			return fmt.Errorf("%s: %w", sink, err)
+		}
+	}
+	return nil
+}
+
+// existingTopLevelDataFiles returns the topLevelDataFiles present in outputDir
+func existingTopLevelDataFiles() ([]string, error) {
+	var names []string
+	for _, name := range topLevelDataFiles {
+		_, err := os.Stat(filepath.Join(outputDir, name))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// encodeJSON renders data the way output files are written
+func encodeJSON(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// contentTypeFor returns the Content-Type to store a file with
+func contentTypeFor(name string) string {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// s3Flags are the flags configuring an S3 sink, shared by the scrape and publish
+type s3Flags struct {
+	destination  *string
+	endpoint     *string
+	region       *string
+	cacheControl *string
+}
+
+func addS3Flags(fs *flag.FlagSet) s3Flags {
+	return s3Flags{
+		destination:  fs.String("s3", "", "Also upload output files to this S3 location, e.g. s3://bucket/prefix. Credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN."),
+		endpoint:     fs.String("s3-endpoint", firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"), "S3-compatible endpoint such as http://localhost:9000, addressed path-style. Defaults to AWS."),
+		region:       fs.String("s3-region", firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"), "S3 region (default us-east-1)"),
+		cacheControl: fs.String("s3-cache-control", defaultCacheControl, "Cache-Control for uploaded data files. Manifests and the registry are always uploaded with no-cache."),
+	}
+}
+
+// sink returns the configured S3 sink, or nil when -s3 is not set
+func (f s3Flags) sink() (outputSink, error) {
+	if *f.destination == "" {
+		return nil, nil
+	}
+	return newS3Sink(*f.destination, *f.endpoint, *f.region, *f.cacheControl)
+}
+
+// firstEnv returns the first of the environment variables that is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// runPublish implements the publish command: upload already scraped files
+func runPublish(args []string) error {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Only upload this tournament's files. Defaults to every tournament in the registry.")
+	s3 := addS3Flags(fs)
+	fs.Parse(args)
+
+	sink, err := s3.sink()
+	if err != nil {
+		return err
+	}
+	if sink == nil {
+		return fmt.Errorf("-s3 is required")
+	}
+
+	registry, err := loadRegistry(filepath.Join(outputDir, registryFile))
+	if err != nil {
+		return err
+	}
+	if _, ok := registry.find(*tournamentFlag); *tournamentFlag != "" && !ok {
+		return fmt.Errorf("tournament %s not found in registry", *tournamentFlag)
+	}
+
+	var names []string
+	for _, t := range registry {
+		if *tournamentFlag != "" && t.ID != *tournamentFlag {
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(outputDir, fmt.Sprintf("tournament-%s-*.json", t.ID)))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			names = append(names, filepath.Base(file))
+		}
+	}
+	topLevel, err := existingTopLevelDataFiles()
+	if err != nil {
+		return err
+	}
+	names = append(names, topLevel...)
+
+	if err := uploadFiles(sink, names); err != nil {
+		return err
+	}
+	fmt.Printf("Uploaded %d files to %s\n", len(names), sink)
+	return nil
+}
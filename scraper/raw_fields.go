@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// rawFields holds the JSON object members of an API payload that the Go type
+// doesn't declare, so they can be written back out unchanged
+type rawFields map[string]json.RawMessage
+
+var rawFieldsType = reflect.TypeOf(rawFields{})
+
+// matchFieldsFile records every undeclared match field melee.gg has returned, under outputDir
+const matchFieldsFile = "match-fields.json"
+
+// FieldSighting is where an undeclared match field was first seen
+type FieldSighting struct {
+	TournamentID string    `json:"tournamentId"`
+	FirstSeen    time.Time `json:"firstSeen"`
+}
+
+// unmarshalKeepingExtras decodes data into v, a pointer to a struct, and returns
+// the object members that none of v's fields take. Like encoding/json, field names
+// match case-insensitively.
+func unmarshalKeepingExtras(data []byte, v interface{}) (rawFields, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil || len(members) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]bool)
+	t := reflect.TypeOf(v).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		known[strings.ToLower(name)] = true
+	}
+
+	extra := make(rawFields)
+	for name, value := range members {
+		if !known[strings.ToLower(name)] {
+			extra[name] = value
+		}
+	}
+	if len(extra) == 0 {
+		return nil, nil
+	}
+	return extra, nil
+}
+
+// marshalWithExtras encodes v, a struct, followed by the extra members in name order
+func marshalWithExtras(v interface{}, extra rawFields) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for i, name := range names {
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(extra[name])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (m *Match) UnmarshalJSON(data []byte) error {
+	type plain Match
+	extra, err := unmarshalKeepingExtras(data, (*plain)(m))
+	m.Extra = extra
+	return err
+}
+
+func (m Match) MarshalJSON() ([]byte, error) {
+	type plain Match
+	return marshalWithExtras(plain(m), m.Extra)
+}
+
+func (c *MatchCompetitor) UnmarshalJSON(data []byte) error {
+	type plain MatchCompetitor
+	extra, err := unmarshalKeepingExtras(data, (*plain)(c))
+	c.Extra = extra
+	return err
+}
+
+func (c MatchCompetitor) MarshalJSON() ([]byte, error) {
+	type plain MatchCompetitor
+	return marshalWithExtras(plain(c), c.Extra)
+}
+
+func (d *MatchDecklist) UnmarshalJSON(data []byte) error {
+	type plain MatchDecklist
+	extra, err := unmarshalKeepingExtras(data, (*plain)(d))
+	d.Extra = extra
+	return err
+}
+
+func (d MatchDecklist) MarshalJSON() ([]byte, error) {
+	type plain MatchDecklist
+	return marshalWithExtras(plain(d), d.Extra)
+}
+
+func (t *MatchTeam) UnmarshalJSON(data []byte) error {
+	type plain MatchTeam
+	extra, err := unmarshalKeepingExtras(data, (*plain)(t))
+	t.Extra = extra
+	return err
+}
+
+func (t MatchTeam) MarshalJSON() ([]byte, error) {
+	type plain MatchTeam
+	return marshalWithExtras(plain(t), t.Extra)
+}
+
+func (p *MatchPlayer) UnmarshalJSON(data []byte) error {
+	type plain MatchPlayer
+	extra, err := unmarshalKeepingExtras(data, (*plain)(p))
+	p.Extra = extra
+	return err
+}
+
+func (p MatchPlayer) MarshalJSON() ([]byte, error) {
+	type plain MatchPlayer
+	return marshalWithExtras(plain(p), p.Extra)
+}
+
+// extraMatchFields lists the paths of every undeclared field in a tournament's
+// matches, such as "Competitors[].Team.Players[].CountryCode"
+func extraMatchFields(allMatches map[int][]Match) []string {
+	seen := make(map[string]bool)
+	add := func(prefix string, extra rawFields) {
+		for name := range extra {
+			seen[prefix+name] = true
+		}
+	}
+
+	for _, matches := range allMatches {
+		for _, match := range matches {
+			add("", match.Extra)
+			for _, competitor := range match.Competitors {
+				add("Competitors[].", competitor.Extra)
+				add("Competitors[].Team.", competitor.Team.Extra)
+				for _, decklist := range competitor.Decklists {
+					add("Competitors[].Decklists[].", decklist.Extra)
+				}
+				for _, player := range competitor.Team.Players {
+					add("Competitors[].Team.Players[].", player.Extra)
+				}
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// loadMatchFields reads the match fields file
+func loadMatchFields(path string) (map[string]FieldSighting, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read match fields %s: %w", path, err)
+	}
+
+	var known map[string]FieldSighting
+	if err := json.Unmarshal(bytes, &known); err != nil {
+		return nil, fmt.Errorf("parse match fields %s: %w", path, err)
+	}
+	if known == nil {
+		known = make(map[string]FieldSighting)
+	}
+	return known, nil
+}
+
+// recordMatchFields adds fields not yet in the match fields file at path and
+// returns them: schema drift since the last scrape that saw new fields
+func recordMatchFields(path, tournamentID string, fields []string, now time.Time) ([]string, error) {
+	known, err := loadMatchFields(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		known = make(map[string]FieldSighting)
+	case err != nil:
+		return nil, err
+	}
+
+	var added []string
+	for _, field := range fields {
+		if _, ok := known[field]; !ok {
+			known[field] = FieldSighting{TournamentID: tournamentID, FirstSeen: now}
+			added = append(added, field)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	if err := writeJSON(path, known); err != nil {
+		return nil, fmt.Errorf("save %s: %w", filepath.Base(path), err)
+	}
+	return added, nil
+}
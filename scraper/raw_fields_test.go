@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const matchWithExtras = `{
+	"ID": 901,
+	"TableNumber": 3,
+	"ResultString": "Alice won 2-0-0",
+	"RoundStarted": "2026-05-01T09:00:00Z",
+	"Competitors": [
+		{
+			"Decklists": [{"DecklistId": "d1", "DecklistName": "Izzet Prowess", "Points": 3}],
+			"Team": {"Players": [{"ID": 1, "DisplayName": "Alice", "CountryCode": "SE"}], "Name": null}
+		},
+		{"Team": {"Players": [{"ID": 2, "DisplayName": "Bob"}]}}
+	]
+}`
+
+func TestMatch_KeepsUndeclaredFields(t *testing.T) {
+	var m Match
+	if err := json.Unmarshal([]byte(matchWithExtras), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.TableNumber != 3 || m.Competitors[0].Team.Players[0].DisplayName != "Alice" {
+		t.Errorf("declared fields not decoded: %+v", m)
+	}
+	if string(m.Extra["ID"]) != "901" || string(m.Competitors[0].Team.Players[0].Extra["CountryCode"]) != `"SE"` {
+		t.Errorf("undeclared fields not kept: %v, %v", m.Extra, m.Competitors[0].Team.Players[0].Extra)
+	}
+	if m.Competitors[1].Team.Players[0].Extra != nil {
+		t.Errorf("expected no extras for Bob, got %v", m.Competitors[1].Team.Players[0].Extra)
+	}
+
+	// Writing the match back out and reading it again loses nothing
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var original, roundTrip map[string]interface{}
+	json.Unmarshal([]byte(matchWithExtras), &original)
+	if err := json.Unmarshal(data, &roundTrip); err != nil {
+		t.Fatalf("marshalled match is not JSON: %v\n%s", err, data)
+	}
+	player := roundTrip["Competitors"].([]interface{})[0].(map[string]interface{})["Team"].(map[string]interface{})["Players"].([]interface{})[0].(map[string]interface{})
+	if roundTrip["ID"] != original["ID"] || roundTrip["RoundStarted"] != original["RoundStarted"] || player["CountryCode"] != "SE" {
+		t.Errorf("undeclared fields not written back: %s", data)
+	}
+}
+
+func TestMatch_WithoutExtrasEncodesAsBefore(t *testing.T) {
+	m := testMatch(t, "Alice", "Bob", "Alice won 2-0-0")
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"TableNumber":1,"ResultString":"Alice won 2-0-0","Competitors":[` +
+		`{"Decklists":null,"Team":{"Players":[{"ID":0,"DisplayName":"Alice","ScreenName":""}]}},` +
+		`{"Decklists":null,"Team":{"Players":[{"ID":0,"DisplayName":"Bob","ScreenName":""}]}}]}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
+
+func TestRecordMatchFields_ReportsNewFieldsOnce(t *testing.T) {
+	var m Match
+	if err := json.Unmarshal([]byte(matchWithExtras), &m); err != nil {
+		t.Fatal(err)
+	}
+	fields := extraMatchFields(map[int][]Match{4: {m}})
+	want := "Competitors[].Decklists[].Points,Competitors[].Team.Name,Competitors[].Team.Players[].CountryCode,ID,RoundStarted"
+	if strings.Join(fields, ",") != want {
+		t.Errorf("got fields %v, want %s", fields, want)
+	}
+
+	path := filepath.Join(t.TempDir(), matchFieldsFile)
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	added, err := recordMatchFields(path, "1", fields[:2], now)
+	if err != nil || len(added) != 2 {
+		t.Fatalf("first scrape: added %v, err %v", added, err)
+	}
+	added, err = recordMatchFields(path, "2", fields, now.Add(time.Hour))
+	if err != nil || strings.Join(added, ",") != strings.Join(fields[2:], ",") {
+		t.Fatalf("second scrape: added %v, err %v", added, err)
+	}
+
+	known, err := loadMatchFields(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(known) != 5 || known["Competitors[].Team.Name"].TournamentID != "1" || known["ID"].TournamentID != "2" {
+		t.Errorf("unexpected sightings: %+v", known)
+	}
+}
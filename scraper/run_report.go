@@ -0,0 +1,21 @@
+package main
+
+import "time"
+
+// RunReport summarises one scrape of a tournament: what was fetched, what failed
+// and which decklists look wrong. CachedDecklists were reused from the previous
+// scrape by decklist ID instead of downloaded. SchemaDrift lists match fields melee.gg returned
+// for the first time (see recordMatchFields).
+type RunReport struct {
+	TournamentID    string             `json:"tournamentId"`
+	StartedAt       time.Time          `json:"startedAt"`
+	FinishedAt      time.Time          `json:"finishedAt"`
+	Rounds          []int              `json:"rounds"`
+	FailedRounds    []int              `json:"failedRounds"`
+	Matches         int                `json:"matches"`
+	Decklists       int                `json:"decklists"`
+	CachedDecklists int                `json:"cachedDecklists"`
+	Validation      *ValidationSummary `json:"validation,omitempty"`
+	IdenticalDecks  []DuplicateGroup   `json:"identicalDecks"`
+	SchemaDrift     []string           `json:"schemaDrift,omitempty"`
+}
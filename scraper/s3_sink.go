@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultCacheControl lets readers cache data files briefly, so a live event's
+// new rounds show up within minutes
+const defaultCacheControl = "public, max-age=300"
+
+// s3UploadAttempts is how many times an upload is tried before giving up. Only
+// network errors, throttling and server errors are retried.
+const s3UploadAttempts = 3
+
+// s3Sink uploads files to an S3 bucket or an S3-compatible store such as MinIO,
+// with requests signed by hand (AWS Signature Version 4) to avoid the SDK
+type s3Sink struct {
+	endpoint     *url.URL // scheme and host; path-style when set, virtual-hosted on AWS otherwise
+	pathStyle    bool
+	bucket       string
+	prefix       string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	cacheControl string
+	client       *http.Client
+	now          func() time.Time
+	retryDelay   time.Duration // before the second attempt, doubling after
+}
+
+// newS3Sink configures an S3 sink for destination, "s3://bucket/prefix". An empty
+// endpoint means AWS; credentials come from the standard AWS environment variables.
+func newS3Sink(destination, endpoint, region, cacheControl string) (*s3Sink, error) {
+	dest, err := url.Parse(destination)
+	if err != nil || dest.Scheme != "s3" || dest.Host == "" {
+		return nil, fmt.Errorf("S3 destination %q is not s3://bucket/prefix", destination)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	s := &s3Sink{
+		bucket:       dest.Host,
+		prefix:       strings.Trim(dest.Path, "/"),
+		region:       region,
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		cacheControl: cacheControl,
+		client:       &http.Client{Timeout: 60 * time.Second},
+		now:          time.Now,
+		retryDelay:   time.Second,
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set to upload to %s", destination)
+	}
+
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	} else {
+		s.pathStyle = true
+	}
+	if s.endpoint, err = url.Parse(endpoint); err != nil || s.endpoint.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint %q is not a URL", endpoint)
+	}
+	return s, nil
+}
+
+func (s *s3Sink) String() string {
+	return "s3://" + strings.TrimSuffix(s.bucket+"/"+s.prefix, "/")
+}
+
+// objectURL returns the URL of the object for a file name
+func (s *s3Sink) objectURL(name string) *url.URL {
+	key := strings.TrimPrefix(s.prefix+"/"+name, "/")
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	return &u
+}
+
+// cacheControlFor returns the Cache-Control for a file. Manifests and the registry
+// say which other files are current, so they are never cached.
+func (s *s3Sink) cacheControlFor(name string) string {
+	if name == registryFile || strings.HasSuffix(name, "-manifest.json") {
+		return "no-cache"
+	}
+	return s.cacheControl
+}
+
+// put uploads a file, retrying transient failures with exponential backoff
+func (s *s3Sink) put(name string, body []byte) error {
+	delay := s.retryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := s.tryPut(name, body)
+		if err == nil {
+			log.Printf("    Uploaded %s/%s", s, name)
+			return nil
+		}
+		if !retry || attempt == s3UploadAttempts {
+			return err
+		}
+		log.Printf("    %v; retrying in %s", err, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// tryPut makes one upload attempt. retry reports whether a failure may be transient.
+func (s *s3Sink) tryPut(name string, body []byte) (retry bool, err error) {
+	u := s.objectURL(name)
+	req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", contentTypeFor(name))
+	if cacheControl := s.cacheControlFor(name); cacheControl != "" {
+		req.Header.Set("Cache-Control", cacheControl)
+	}
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("upload %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("upload %s: %s", name, s3ErrorMessage(resp))
+	}
+	return false, nil
+}
+
+// s3ErrorMessage describes a failed response, using the error document S3 returns
+func s3ErrorMessage(resp *http.Response) string {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var doc struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(data, &doc) == nil && doc.Code != "" {
+		return fmt.Sprintf("%s: %s: %s", resp.Status, doc.Code, doc.Message)
+	}
+	return resp.Status
+}
+
+// sign adds AWS Signature Version 4 headers to req, signing every header it has
+func (s *s3Sink) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// s3EscapePath URI-encodes each segment of an object path the way SigV4 expects:
+// everything but unreserved characters, keeping the slashes
+func s3EscapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
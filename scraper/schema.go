@@ -0,0 +1,425 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// currentSchemaVersions is the schema version of each output kind this scraper
+// writes. Bump a kind's version whenever the JSON it encodes to changes shape,
+// even by an optional field, and register a migration from the previous version.
+//
+// History:
+//   - v1 of matches, decklists, player-decks and stats is the baseline shape, as in
+//     the files under data/; v1 of every later kind is the shape it was added with
+//   - decklists v2: decklistId, mainHash and sideboardHash on every deck, plus the
+//     optional classifiedArchetype, card metadata, summary, violations and similar
+//   - matches v2: melee.gg fields the scraper doesn't declare are kept (see rawFields)
+//   - report v2: schemaDrift
+//   - clusters v2: silhouette
+var currentSchemaVersions = map[string]int{
+	"matches":           2,
+	"player-decks":      1,
+	"decklists":         2,
+	"stats":             1,
+	"archetype-changes": 1,
+	"classification":    1,
+	"archetype-decks":   1,
+	"cards":             1,
+	"report":            2,
+	"clusters":          2,
+	"duplicates":        1,
+	"cooccurrence":      1,
+}
+
+// selfVersionedKinds are written by analysis commands rather than the scrape, so
+// they are not in the manifest. Their files carry their own schemaVersion field
+// instead, and files without one are v1.
+var selfVersionedKinds = map[string]bool{
+	"clusters":     true,
+	"duplicates":   true,
+	"cooccurrence": true,
+}
+
+// manifestSchemaVersion is the schema version of the manifest itself
+const manifestSchemaVersion = 1
+
+// migration upgrades one kind of output file from version from to from+1. related
+// loads another of the same tournament's files, for migrations that need it.
+type migration struct {
+	kind    string
+	from    int
+	summary string
+	apply   func(data []byte, related func(kind string, v interface{}) error) (interface{}, error)
+}
+
+var migrations = []migration{
+	{"decklists", 1, "add decklist IDs from the matches file and deck hashes", migrateDecklistsV1},
+	{"matches", 1, "no changes: v1 files have no undeclared fields to keep", migrateMatchesV1},
+	{"report", 1, "no changes: schemaDrift is optional", migrateReportV1},
+	{"clusters", 1, "compute the silhouette score from the decklists file", migrateClustersV1},
+}
+
+// findMigration returns the migration upgrading kind from version from
+func findMigration(kind string, from int) (migration, bool) {
+	for _, m := range migrations {
+		if m.kind == kind && m.from == from {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+// migrateData upgrades data of the given kind from version to the current version,
+// returning the upgraded value and the summaries of the migrations applied
+func migrateData(kind string, version int, data []byte, related func(kind string, v interface{}) error) (interface{}, []string, error) {
+	current, ok := currentSchemaVersions[kind]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown output kind %q", kind)
+	}
+	if version > current {
+		return nil, nil, fmt.Errorf("%s schema v%d is newer than this scraper's v%d", kind, version, current)
+	}
+
+	var applied []string
+	var value interface{}
+	for ; version < current; version++ {
+		m, ok := findMigration(kind, version)
+		if !ok {
+			return nil, nil, fmt.Errorf("no migration for %s from v%d", kind, version)
+		}
+		var err error
+		if value, err = m.apply(data, related); err != nil {
+			return nil, nil, fmt.Errorf("migrate %s v%d: %w", kind, version, err)
+		}
+		if data, err = json.Marshal(value); err != nil {
+			return nil, nil, fmt.Errorf("migrate %s v%d: %w", kind, version, err)
+		}
+		applied = append(applied, fmt.Sprintf("v%d -> v%d: %s", version, version+1, m.summary))
+	}
+	return value, applied, nil
+}
+
+// migrateDecklistsV1 fills in decklist IDs from the matches file, when there is one,
+// and computes deck hashes. The optional fields v2 added need card data, rules or
+// the whole tournament, so they are left unset until the next scrape.
+func migrateDecklistsV1(data []byte, related func(kind string, v interface{}) error) (interface{}, error) {
+	var decklists []DeckInfo
+	if err := json.Unmarshal(data, &decklists); err != nil {
+		return nil, err
+	}
+
+	var matches map[int][]Match
+	err := related("matches", &matches)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	ids := decklistIDsFromMatches(matches)
+	for i := range decklists {
+		if decklists[i].DecklistID == "" {
+			decklists[i].DecklistID = ids[normalizePlayerName(decklists[i].PlayerName)]
+		}
+	}
+
+	hashDecklists(decklists)
+	return decklists, nil
+}
+
+// migrateMatchesV1 re-encodes a v1 matches file, which v2 reads unchanged
+func migrateMatchesV1(data []byte, related func(kind string, v interface{}) error) (interface{}, error) {
+	var matches map[int][]Match
+	if err := json.Unmarshal(data, &matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// migrateReportV1 re-encodes a v1 run report, which v2 reads unchanged
+func migrateReportV1(data []byte, related func(kind string, v interface{}) error) (interface{}, error) {
+	var report RunReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// migrateClustersV1 computes the silhouette score of a saved clustering from the
+// tournament's decklists. Members whose deck is gone are left out of the score.
+func migrateClustersV1(data []byte, related func(kind string, v interface{}) error) (interface{}, error) {
+	var report ClusterReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	var decklists []DeckInfo
+	if err := related("decklists", &decklists); err != nil {
+		return nil, err
+	}
+	byPlayer := make(map[string]DeckInfo, len(decklists))
+	for _, deck := range decklists {
+		byPlayer[deck.PlayerName] = deck
+	}
+
+	var vectors []map[string]float64
+	var assignment []int
+	for c, cluster := range report.Clusters {
+		for _, member := range cluster.Members {
+			if deck, ok := byPlayer[member]; ok && len(deck.MainDeck) > 0 {
+				vectors = append(vectors, deckVector(deck))
+				assignment = append(assignment, c)
+			}
+		}
+	}
+	dist := make([][]float64, len(vectors))
+	for i := range dist {
+		dist[i] = make([]float64, len(vectors))
+		for j := range vectors {
+			dist[i][j] = cosineDistance(vectors[i], vectors[j])
+		}
+	}
+
+	report.Silhouette = silhouette(dist, assignment, len(report.Clusters))
+	report.SchemaVersion = 2
+	return report, nil
+}
+
+// decklistIDsFromMatches maps each normalized player name to the melee.gg decklist
+// ID they registered
+func decklistIDsFromMatches(allMatches map[int][]Match) map[string]string {
+	decklistIDs := make(map[string]string)
+	for _, matches := range allMatches {
+		for _, match := range matches {
+			for _, competitor := range match.Competitors {
+				if len(competitor.Decklists) == 0 || len(competitor.Team.Players) == 0 {
+					continue
+				}
+				if id := competitor.Decklists[0].DecklistID; id != "" {
+					decklistIDs[normalizePlayerName(competitor.Team.Players[0].DisplayName)] = id
+				}
+			}
+		}
+	}
+	return decklistIDs
+}
+
+// loadOutputManifest reads a tournament's manifest. Tournaments scraped before
+// manifests existed get one inferred from the files on disk, all at schema v1.
+func loadOutputManifest(tournamentID string) (*OutputManifest, error) {
+	var manifest OutputManifest
+	err := loadJSON(tournamentID, "manifest", &manifest)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return inferLegacyManifest(tournamentID)
+	case err != nil:
+		return nil, err
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]ManifestFile)
+	}
+	return &manifest, nil
+}
+
+// inferLegacyManifest lists every output file present for a tournament at schema v1
+func inferLegacyManifest(tournamentID string) (*OutputManifest, error) {
+	manifest := &OutputManifest{
+		SchemaVersion:  manifestSchemaVersion,
+		TournamentID:   tournamentID,
+		ScraperVersion: "unknown",
+		Files:          make(map[string]ManifestFile),
+	}
+	for _, kind := range schemaKinds() {
+		if selfVersionedKinds[kind] {
+			continue
+		}
+		path := dataFilePath(tournamentID, kind)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		if err := manifest.record(kind, countRows(data)); err != nil {
+			return nil, err
+		}
+		entry := manifest.Files[kind]
+		entry.SchemaVersion = 1
+		manifest.Files[kind] = entry
+	}
+	return manifest, nil
+}
+
+// schemaKinds returns the versioned output kinds in a stable order
+func schemaKinds() []string {
+	kinds := make([]string, 0, len(currentSchemaVersions))
+	for kind := range currentSchemaVersions {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// fileSchemaVersion returns the recorded schema version of kind. Entries written
+// before schema versions were recorded are v1.
+func (m *OutputManifest) fileSchemaVersion(kind string) int {
+	if entry, ok := m.Files[kind]; ok && entry.SchemaVersion > 0 {
+		return entry.SchemaVersion
+	}
+	return 1
+}
+
+// countRows estimates a file's row count when the writer didn't record one:
+// array length, the summed lengths of an object of arrays (matches by round),
+// the size of an "archetypes" or "cards" map, or the number of keys
+func countRows(data []byte) int {
+	var array []json.RawMessage
+	if json.Unmarshal(data, &array) == nil {
+		return len(array)
+	}
+	var object map[string]json.RawMessage
+	if json.Unmarshal(data, &object) != nil {
+		return 0
+	}
+	for _, key := range []string{"archetypes", "cards"} {
+		var nested map[string]json.RawMessage
+		if raw, ok := object[key]; ok && json.Unmarshal(raw, &nested) == nil {
+			return len(nested)
+		}
+	}
+	rows := 0
+	for _, raw := range object {
+		if json.Unmarshal(raw, &array) != nil {
+			return len(object)
+		}
+		rows += len(array)
+	}
+	return rows
+}
+
+// migrateTournament upgrades every output file of a tournament to the current schema
+// and updates its manifest. It returns the number of files migrated.
+func migrateTournament(tournamentID string, dryRun bool) (int, error) {
+	manifest, err := loadOutputManifest(tournamentID)
+	if err != nil {
+		return 0, err
+	}
+	related := func(kind string, v interface{}) error { return loadJSON(tournamentID, kind, v) }
+
+	migrated, manifestChanged := 0, false
+	for _, kind := range schemaKinds() {
+		path := dataFilePath(tournamentID, kind)
+		var data []byte
+		var version int
+		entry, listed := manifest.Files[kind]
+		if selfVersionedKinds[kind] {
+			if data, err = os.ReadFile(path); errors.Is(err, fs.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return migrated, fmt.Errorf("read %s: %w", path, err)
+			}
+			version = embeddedSchemaVersion(data)
+		} else {
+			if !listed {
+				continue
+			}
+			version = manifest.fileSchemaVersion(kind)
+		}
+		if version == currentSchemaVersions[kind] {
+			continue
+		}
+
+		if data == nil {
+			if data, err = os.ReadFile(path); err != nil {
+				return migrated, fmt.Errorf("read %s: %w", path, err)
+			}
+		}
+		value, applied, err := migrateData(kind, version, data, related)
+		if err != nil {
+			return migrated, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, step := range applied {
+			log.Printf("  %s %s", filepath.Base(path), step)
+		}
+		migrated++
+		if dryRun {
+			continue
+		}
+
+		if err := writeJSON(path, value); err != nil {
+			return migrated, err
+		}
+		if selfVersionedKinds[kind] {
+			continue
+		}
+		if err := manifest.record(kind, entry.Rows); err != nil {
+			return migrated, err
+		}
+		manifestChanged = true
+	}
+
+	if manifestChanged {
+		now := time.Now().UTC()
+		manifest.MigratedAt = &now
+		manifest.SchemaVersion = manifestSchemaVersion
+		if err := saveOutputManifest(tournamentID, manifest); err != nil {
+			return migrated, err
+		}
+	}
+	return migrated, nil
+}
+
+// embeddedSchemaVersion reads the schemaVersion field of a self-versioned file,
+// 1 when it has none
+func embeddedSchemaVersion(data []byte) int {
+	var header struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	if json.Unmarshal(data, &header) != nil || header.SchemaVersion < 1 {
+		return 1
+	}
+	return header.SchemaVersion
+}
+
+// runMigrate implements the migrate command
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Only migrate this tournament. Defaults to every tournament in the registry.")
+	dryRunFlag := fs.Bool("dry-run", false, "Report the migrations that would run without writing files")
+	fs.Parse(args)
+
+	tournamentIDs := []string{*tournamentFlag}
+	if *tournamentFlag == "" {
+		registry, err := loadRegistry(filepath.Join(outputDir, registryFile))
+		if err != nil {
+			return err
+		}
+		tournamentIDs = nil
+		for _, t := range registry {
+			tournamentIDs = append(tournamentIDs, t.ID)
+		}
+	}
+
+	total := 0
+	for _, id := range tournamentIDs {
+		log.Printf("Tournament %s", id)
+		migrated, err := migrateTournament(id, *dryRunFlag)
+		total += migrated
+		if err != nil {
+			return err
+		}
+	}
+
+	if *dryRunFlag {
+		log.Printf("%d files need migrating", total)
+	} else {
+		log.Printf("Migrated %d files", total)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fixtureLoader loads related files from testdata/schema, at their current version
+func fixtureLoader(t *testing.T) func(kind string, v interface{}) error {
+	return func(kind string, v interface{}) error {
+		data, err := os.ReadFile(filepath.Join("testdata", "schema", fmt.Sprintf("%s-v%d.json", kind, currentSchemaVersions[kind])))
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, v)
+	}
+}
+
+func TestMigrations_EveryPastVersionHasFixtureAndMigration(t *testing.T) {
+	for _, kind := range schemaKinds() {
+		for version := 1; version < currentSchemaVersions[kind]; version++ {
+			if _, ok := findMigration(kind, version); !ok {
+				t.Errorf("%s: no migration from v%d", kind, version)
+			}
+
+			path := filepath.Join("testdata", "schema", fmt.Sprintf("%s-v%d.json", kind, version))
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Errorf("%s: missing fixture for v%d: %v", kind, version, err)
+				continue
+			}
+
+			value, applied, err := migrateData(kind, version, data, fixtureLoader(t))
+			if err != nil {
+				t.Errorf("%s v%d: %v", kind, version, err)
+				continue
+			}
+			if len(applied) != currentSchemaVersions[kind]-version {
+				t.Errorf("%s v%d: expected %d steps, got %v", kind, version, currentSchemaVersions[kind]-version, applied)
+			}
+			if _, err := json.Marshal(value); err != nil {
+				t.Errorf("%s v%d: migrated value doesn't encode: %v", kind, version, err)
+			}
+		}
+	}
+}
+
+func TestMigrateDecklistsV1(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "schema", "decklists-v1.json"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	value, _, err := migrateData("decklists", 1, data, fixtureLoader(t))
+	if err != nil {
+		t.Fatalf("migrateData returned error: %v", err)
+	}
+	decklists := value.([]DeckInfo)
+
+	if decklists[0].DecklistID != "a9db90ec-540a-4f25-8bdc-b3dd004ff8f0" || decklists[1].DecklistID != "811f1445-0e10-4795-b675-b3e000096866" {
+		t.Errorf("decklist IDs should come from the matches file: %q, %q", decklists[0].DecklistID, decklists[1].DecklistID)
+	}
+	if decklists[0].MainHash != cardListHash(decklists[0].MainDeck) || decklists[0].SideboardHash != cardListHash(decklists[0].Sideboard) {
+		t.Errorf("hashes not set: %+v", decklists[0])
+	}
+	if decklists[2].DecklistID != "" || decklists[2].MainHash != "" {
+		t.Errorf("a player missing from matches with no cards should be left alone: %+v", decklists[2])
+	}
+
+	noMatches := func(kind string, v interface{}) error { return os.ErrNotExist }
+	value, _, err = migrateData("decklists", 1, data, noMatches)
+	if err != nil {
+		t.Fatalf("a missing matches file should not fail the migration: %v", err)
+	}
+	if decks := value.([]DeckInfo); decks[0].DecklistID != "" || decks[0].MainHash == "" {
+		t.Errorf("without matches only hashes are added: %+v", decks[0])
+	}
+}
+
+func TestMigrateData_Errors(t *testing.T) {
+	if _, _, err := migrateData("decklists", currentSchemaVersions["decklists"]+1, []byte("[]"), fixtureLoader(t)); err == nil {
+		t.Error("expected error for a version newer than the scraper")
+	}
+	if _, _, err := migrateData("nonsense", 1, []byte("[]"), fixtureLoader(t)); err == nil {
+		t.Error("expected error for an unknown kind")
+	}
+	if _, _, err := migrateData("decklists", 1, []byte("{not json"), fixtureLoader(t)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestCountRows(t *testing.T) {
+	tests := map[string]int{
+		`[{"a": 1}, {"a": 2}]`:                        2,
+		`{"4": [{}, {}], "5": [{}]}`:                  3,
+		`{"archetypes": {"Izzet": {}, "Mono-G": {}}}`: 2,
+		`{"totalDecks": 3, "cards": {"Opt": {}}}`:     1,
+		`{"Alice": "Izzet", "Bob": "Izzet"}`:          2,
+		`"not a container"`:                           0,
+	}
+	for input, want := range tests {
+		if got := countRows([]byte(input)); got != want {
+			t.Errorf("countRows(%s): expected %d, got %d", input, want, got)
+		}
+	}
+}
+
+func TestMigrateClustersV1(t *testing.T) {
+	decks := []DeckInfo{
+		{PlayerName: "Alice", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Shock"}}},
+		{PlayerName: "Bob", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 3, Name: "Shock"}}},
+		{PlayerName: "Carol", MainDeck: []CardInfo{{Quantity: 4, Name: "Llanowar Elves"}}},
+	}
+	want := clusterDecks(decks, 2)
+
+	v1 := *want
+	v1.Silhouette = 0
+	data, err := json.Marshal(v1)
+	if err != nil {
+		t.Fatalf("encode v1 report: %v", err)
+	}
+	related := func(kind string, v interface{}) error {
+		*v.(*[]DeckInfo) = decks
+		return nil
+	}
+
+	value, _, err := migrateData("clusters", 1, data, related)
+	if err != nil {
+		t.Fatalf("migrateData returned error: %v", err)
+	}
+	report := value.(ClusterReport)
+	if report.SchemaVersion != 2 {
+		t.Errorf("expected schemaVersion 2, got %d", report.SchemaVersion)
+	}
+	if math.Abs(report.Silhouette-want.Silhouette) > 1e-9 || report.Silhouette <= 0 {
+		t.Errorf("expected silhouette %v, got %v", want.Silhouette, report.Silhouette)
+	}
+
+	noDecklists := func(kind string, v interface{}) error { return os.ErrNotExist }
+	if _, _, err := migrateData("clusters", 1, data, noDecklists); err == nil {
+		t.Error("expected error without a decklists file")
+	}
+}
+
+func TestEmbeddedSchemaVersion(t *testing.T) {
+	tests := map[string]int{
+		`{"schemaVersion": 2, "k": 3}`: 2,
+		`{"k": 3}`:                     1,
+		`[]`:                           1,
+	}
+	for input, want := range tests {
+		if got := embeddedSchemaVersion([]byte(input)); got != want {
+			t.Errorf("embeddedSchemaVersion(%s): expected %d, got %d", input, want, got)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scrapeVersion is one version of a tournament's data files: the current files, a
+// data directory, a history snapshot or a git revision. load behaves like loadJSON,
+// wrapping fs.ErrNotExist for missing files.
+type scrapeVersion struct {
+	label string
+	load  func(kind string, v interface{}) error
+}
+
+// ResultChange is a match whose result string differs between two versions.
+// CountedInStats is set when both players have an archetype, so the published
+// archetype stats included the match.
+type ResultChange struct {
+	Round          int    `json:"round"`
+	Player1        string `json:"player1"`
+	Player2        string `json:"player2"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	WinnerChanged  bool   `json:"winnerChanged"`
+	CountedInStats bool   `json:"countedInStats"`
+}
+
+// LabelChange is a player whose archetype label differs between two versions.
+// From or To is empty for a player who gained or lost a label.
+type LabelChange struct {
+	Player string `json:"player"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// DecklistChange is a deck added, removed or changed between two versions.
+// Swaps is how many cards changed (see cardSwaps).
+type DecklistChange struct {
+	Player    string `json:"player"`
+	Archetype string `json:"archetype"`
+	Change    string `json:"change"`
+	Swaps     int    `json:"swaps,omitempty"`
+}
+
+// ScrapeDiff is the output of the diff-scrape command for one tournament
+type ScrapeDiff struct {
+	TournamentID     string           `json:"tournamentId"`
+	From             string           `json:"from"`
+	To               string           `json:"to"`
+	AddedRounds      []int            `json:"addedRounds"`
+	RemovedRounds    []int            `json:"removedRounds"`
+	AddedMatches     int              `json:"addedMatches"`
+	RemovedMatches   int              `json:"removedMatches"`
+	ChangedResults   []ResultChange   `json:"changedResults"`
+	ArchetypeChanges []LabelChange    `json:"archetypeChanges"`
+	DecklistChanges  []DecklistChange `json:"decklistChanges"`
+}
+
+// empty reports whether the two versions hold the same data
+func (d *ScrapeDiff) empty() bool {
+	return len(d.AddedRounds) == 0 && len(d.RemovedRounds) == 0 && d.AddedMatches == 0 && d.RemovedMatches == 0 &&
+		len(d.ChangedResults) == 0 && len(d.ArchetypeChanges) == 0 && len(d.DecklistChanges) == 0
+}
+
+// openScrapeVersion resolves a -from/-to argument for a tournament:
+// "current" (the files in outputDir), "git:<rev>", "snapshot:<id>" or a data directory
+func openScrapeVersion(spec, tournamentID string) (scrapeVersion, error) {
+	switch {
+	case spec == "current":
+		return scrapeVersion{label: "current", load: func(kind string, v interface{}) error { return loadJSON(tournamentID, kind, v) }}, nil
+	case strings.HasPrefix(spec, "git:"):
+		rev := strings.TrimPrefix(spec, "git:")
+		return scrapeVersion{label: spec, load: func(kind string, v interface{}) error { return loadJSONAtRevision(rev, tournamentID, kind, v) }}, nil
+	case strings.HasPrefix(spec, "snapshot:"):
+		history := tournamentHistory(tournamentID)
+		s, err := history.find(strings.TrimPrefix(spec, "snapshot:"), time.Time{})
+		if err != nil {
+			return scrapeVersion{}, err
+		}
+		return scrapeVersion{label: spec, load: func(kind string, v interface{}) error { return history.load(s, kind, v) }}, nil
+	default:
+		info, err := os.Stat(spec)
+		if err != nil {
+			return scrapeVersion{}, fmt.Errorf("version %q is not current, git:<rev>, snapshot:<id> or a directory: %w", spec, err)
+		}
+		if !info.IsDir() {
+			return scrapeVersion{}, fmt.Errorf("version %q is not a directory", spec)
+		}
+		return scrapeVersion{label: spec, load: func(kind string, v interface{}) error {
+			path := filepath.Join(spec, filepath.Base(dataFilePath(tournamentID, kind)))
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("read %s: %w", path, err)
+			}
+			if err := json.Unmarshal(data, v); err != nil {
+				return fmt.Errorf("parse %s: %w", path, err)
+			}
+			return nil
+		}}, nil
+	}
+}
+
+// loadJSONAtRevision reads a tournament's file as committed at a git revision
+func loadJSONAtRevision(rev, tournamentID, kind string, v interface{}) error {
+	name := filepath.Base(dataFilePath(tournamentID, kind))
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", outputDir, "show", rev+":./"+name)
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if strings.Contains(message, "does not exist") || strings.Contains(message, "exists on disk, but not in") {
+			return fmt.Errorf("%s at %s: %w", name, rev, os.ErrNotExist)
+		}
+		return fmt.Errorf("git show %s:%s: %v: %s", rev, name, err, message)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse %s at %s: %w", name, rev, err)
+	}
+	return nil
+}
+
+// scrapeData is the part of a version diff-scrape compares. Missing files are empty.
+type scrapeData struct {
+	matches     map[int][]Match
+	playerDecks map[string]string
+	decklists   []DeckInfo
+}
+
+func loadScrapeData(version scrapeVersion) (*scrapeData, error) {
+	data := &scrapeData{}
+	for kind, v := range map[string]interface{}{"matches": &data.matches, "player-decks": &data.playerDecks, "decklists": &data.decklists} {
+		if err := version.load(kind, v); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s: %w", version.label, err)
+		}
+	}
+	return data, nil
+}
+
+// diffScrapes compares two versions of a tournament's data
+func diffScrapes(tournamentID string, from, to *scrapeData) *ScrapeDiff {
+	diff := &ScrapeDiff{
+		TournamentID:     tournamentID,
+		AddedRounds:      []int{},
+		RemovedRounds:    []int{},
+		ChangedResults:   []ResultChange{},
+		ArchetypeChanges: []LabelChange{},
+		DecklistChanges:  []DecklistChange{},
+	}
+
+	for round := range to.matches {
+		if _, ok := from.matches[round]; !ok {
+			diff.AddedRounds = append(diff.AddedRounds, round)
+		}
+	}
+	for round := range from.matches {
+		if _, ok := to.matches[round]; !ok {
+			diff.RemovedRounds = append(diff.RemovedRounds, round)
+		}
+	}
+	sort.Ints(diff.AddedRounds)
+	sort.Ints(diff.RemovedRounds)
+
+	// Matches in rounds both versions have, by round and players
+	for round, toMatches := range to.matches {
+		fromMatches, ok := from.matches[round]
+		if !ok {
+			continue
+		}
+		before := make(map[string]Match)
+		for _, match := range fromMatches {
+			if sides, ok := splitMatch(match); ok {
+				before[sidesKey(sides)] = match
+			}
+		}
+		for _, match := range toMatches {
+			sides, ok := splitMatch(match)
+			if !ok {
+				continue
+			}
+			key := sidesKey(sides)
+			old, ok := before[key]
+			if !ok {
+				diff.AddedMatches++
+				continue
+			}
+			delete(before, key)
+			if old.ResultString == match.ResultString {
+				continue
+			}
+			oldSides, _ := splitMatch(old)
+			diff.ChangedResults = append(diff.ChangedResults, ResultChange{
+				Round:         round,
+				Player1:       sides.Players[0],
+				Player2:       sides.Players[1],
+				From:          old.ResultString,
+				To:            match.ResultString,
+				WinnerChanged: winnerName(oldSides) != winnerName(sides),
+				CountedInStats: sides.Players[1] != "" &&
+					from.playerDecks[normalizePlayerName(sides.Players[0])] != "" &&
+					from.playerDecks[normalizePlayerName(sides.Players[1])] != "",
+			})
+		}
+		diff.RemovedMatches += len(before)
+	}
+	sort.Slice(diff.ChangedResults, func(i, j int) bool {
+		if diff.ChangedResults[i].Round != diff.ChangedResults[j].Round {
+			return diff.ChangedResults[i].Round < diff.ChangedResults[j].Round
+		}
+		return diff.ChangedResults[i].Player1 < diff.ChangedResults[j].Player1
+	})
+
+	// A player missing from one version has an empty label there, so gaining or
+	// losing a label is a change too
+	players := make(map[string]bool)
+	for player := range from.playerDecks {
+		players[player] = true
+	}
+	for player := range to.playerDecks {
+		players[player] = true
+	}
+	for player := range players {
+		if old, label := from.playerDecks[player], to.playerDecks[player]; old != label {
+			diff.ArchetypeChanges = append(diff.ArchetypeChanges, LabelChange{Player: player, From: old, To: label})
+		}
+	}
+	sort.Slice(diff.ArchetypeChanges, func(i, j int) bool { return diff.ArchetypeChanges[i].Player < diff.ArchetypeChanges[j].Player })
+
+	diff.DecklistChanges = diffDecklists(from.decklists, to.decklists)
+	return diff
+}
+
+// sidesKey identifies a match within a round by its players, regardless of order
+func sidesKey(sides matchSides) string {
+	players := []string{normalizePlayerName(sides.Players[0]), normalizePlayerName(sides.Players[1])}
+	sort.Strings(players)
+	return players[0] + "|" + players[1]
+}
+
+// winnerName is the normalized name of a match's winner, or "" for a draw
+func winnerName(sides matchSides) string {
+	if sides.Winner < 0 {
+		return ""
+	}
+	return normalizePlayerName(sides.Players[sides.Winner])
+}
+
+// diffDecklists lists decks added, removed or with different cards, by player
+func diffDecklists(from, to []DeckInfo) []DecklistChange {
+	before := make(map[string]DeckInfo)
+	for _, deck := range from {
+		before[normalizePlayerName(deck.PlayerName)] = deck
+	}
+
+	changes := []DecklistChange{}
+	for _, deck := range to {
+		key := normalizePlayerName(deck.PlayerName)
+		old, ok := before[key]
+		delete(before, key)
+		switch {
+		case !ok:
+			changes = append(changes, DecklistChange{Player: deck.PlayerName, Archetype: deck.Archetype, Change: "added"})
+		case cardListHash(old.MainDeck) != cardListHash(deck.MainDeck) || cardListHash(old.Sideboard) != cardListHash(deck.Sideboard):
+			changes = append(changes, DecklistChange{Player: deck.PlayerName, Archetype: deck.Archetype, Change: "changed",
+				Swaps: cardSwaps(deckVector(old), deckVector(deck))})
+		}
+	}
+	for _, deck := range before {
+		changes = append(changes, DecklistChange{Player: deck.PlayerName, Archetype: deck.Archetype, Change: "removed"})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Player < changes[j].Player })
+	return changes
+}
+
+// formatScrapeDiff renders a diff as text
+func formatScrapeDiff(d *ScrapeDiff) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Tournament %s: %s → %s\n", d.TournamentID, d.From, d.To)
+	if d.empty() {
+		b.WriteString("  No changes\n")
+		return b.String()
+	}
+	if len(d.AddedRounds) > 0 {
+		fmt.Fprintf(&b, "  Added rounds: %v\n", d.AddedRounds)
+	}
+	if len(d.RemovedRounds) > 0 {
+		fmt.Fprintf(&b, "  Removed rounds: %v\n", d.RemovedRounds)
+	}
+	if d.AddedMatches > 0 || d.RemovedMatches > 0 {
+		fmt.Fprintf(&b, "  Matches in existing rounds: %d added, %d removed\n", d.AddedMatches, d.RemovedMatches)
+	}
+	for _, c := range d.ChangedResults {
+		note := ""
+		if c.WinnerChanged {
+			note = " [winner changed]"
+		}
+		if c.CountedInStats {
+			note += " [affects stats]"
+		}
+		opponent := c.Player2
+		if opponent == "" {
+			opponent = "(bye)"
+		}
+		fmt.Fprintf(&b, "  Round %d %s vs %s: %q → %q%s\n", c.Round, c.Player1, opponent, c.From, c.To, note)
+	}
+	for _, c := range d.ArchetypeChanges {
+		fmt.Fprintf(&b, "  Archetype of %s: %q → %q\n", c.Player, c.From, c.To)
+	}
+	for _, c := range d.DecklistChanges {
+		if c.Change == "changed" {
+			fmt.Fprintf(&b, "  Decklist of %s (%s) changed: %d swaps\n", c.Player, c.Archetype, c.Swaps)
+		} else {
+			fmt.Fprintf(&b, "  Decklist of %s (%s) %s\n", c.Player, c.Archetype, c.Change)
+		}
+	}
+	return b.String()
+}
+
+// runDiffScrape implements the diff-scrape command
+func runDiffScrape(args []string) error {
+	fs := flag.NewFlagSet("diff-scrape", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Only compare this tournament. Defaults to every tournament in the registry.")
+	fromFlag := fs.String("from", "git:HEAD", "Old version: current, git:<rev>, snapshot:<id> or a data directory")
+	toFlag := fs.String("to", "current", "New version, in the same forms as -from")
+	jsonFlag := fs.Bool("json", false, "Print the differences as JSON")
+	failFlag := fs.Bool("fail-on-corrections", false, "Exit with an error when a result counted in the stats changed")
+	fs.Parse(args)
+
+	tournamentIDs := []string{*tournamentFlag}
+	if *tournamentFlag == "" {
+		registry, err := loadRegistry(filepath.Join(outputDir, registryFile))
+		if err != nil {
+			return err
+		}
+		tournamentIDs = nil
+		for _, t := range registry {
+			tournamentIDs = append(tournamentIDs, t.ID)
+		}
+	}
+
+	diffs := []*ScrapeDiff{}
+	corrections := 0
+	for _, id := range tournamentIDs {
+		var data [2]*scrapeData
+		for i, spec := range []string{*fromFlag, *toFlag} {
+			version, err := openScrapeVersion(spec, id)
+			if err != nil {
+				return fmt.Errorf("tournament %s: %w", id, err)
+			}
+			if data[i], err = loadScrapeData(version); err != nil {
+				return fmt.Errorf("tournament %s: %w", id, err)
+			}
+		}
+
+		diff := diffScrapes(id, data[0], data[1])
+		diff.From, diff.To = *fromFlag, *toFlag
+		diffs = append(diffs, diff)
+		for _, c := range diff.ChangedResults {
+			if c.CountedInStats {
+				corrections++
+			}
+		}
+		if !*jsonFlag {
+			fmt.Print(formatScrapeDiff(diff))
+		}
+	}
+
+	if *jsonFlag {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diffs); err != nil {
+			return err
+		}
+	}
+	if corrections > 0 {
+		log.Printf("%d corrected results were counted in the published stats", corrections)
+		if *failFlag {
+			return fmt.Errorf("%d corrected results", corrections)
+		}
+	}
+	return nil
+}
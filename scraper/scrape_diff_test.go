@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffScrapes(t *testing.T) {
+	archetypes := map[string]string{"alice": "Izzet Prowess", "bob": "Jeskai Control", "carol": "Mono Red", "dave": ""}
+	from := &scrapeData{
+		matches: map[int][]Match{
+			4: {
+				testMatch(t, "Alice", "Bob", "Alice won 2-1-0"),
+				testMatch(t, "Carol", "Dave", "Carol won 2-0-0"),
+			},
+		},
+		playerDecks: archetypes,
+		decklists: []DeckInfo{
+			{PlayerName: "Alice", Archetype: "Izzet Prowess", MainDeck: []CardInfo{{Name: "Island", Quantity: 20}}},
+			{PlayerName: "Bob", Archetype: "Jeskai Control", MainDeck: []CardInfo{{Name: "Plains", Quantity: 20}}},
+		},
+	}
+	to := &scrapeData{
+		matches: map[int][]Match{
+			4: {
+				// Judges fixed the result; players listed the other way round
+				testMatch(t, "Bob", "Alice", "Bob won 2-1-0"),
+				testMatch(t, "Carol", "Dave", "Carol won 2-1-0"),
+			},
+			5: {testMatch(t, "Alice", "Carol", "Alice won 2-0-0")},
+		},
+		playerDecks: map[string]string{"alice": "Izzet Prowess", "bob": "Azorius Control", "carol": "Mono Red", "erin": "Mono Red"},
+		decklists: []DeckInfo{
+			{PlayerName: "Alice", Archetype: "Izzet Prowess", MainDeck: []CardInfo{{Name: "Island", Quantity: 19}, {Name: "Mountain", Quantity: 1}}},
+			{PlayerName: "Erin", Archetype: "Mono Red", MainDeck: []CardInfo{{Name: "Mountain", Quantity: 20}}},
+		},
+	}
+
+	diff := diffScrapes("1", from, to)
+	if len(diff.AddedRounds) != 1 || diff.AddedRounds[0] != 5 || len(diff.RemovedRounds) != 0 {
+		t.Errorf("expected round 5 added, got added %v removed %v", diff.AddedRounds, diff.RemovedRounds)
+	}
+	if diff.AddedMatches != 0 || diff.RemovedMatches != 0 {
+		t.Errorf("expected round 4 matches to pair up, got %d added %d removed", diff.AddedMatches, diff.RemovedMatches)
+	}
+	if len(diff.ChangedResults) != 2 {
+		t.Fatalf("expected 2 changed results, got %+v", diff.ChangedResults)
+	}
+	if c := diff.ChangedResults[0]; c.Player1 != "Bob" || !c.WinnerChanged || !c.CountedInStats {
+		t.Errorf("expected Bob's win to be a counted correction, got %+v", c)
+	}
+	// Dave had no archetype, so the match was not in the stats
+	if c := diff.ChangedResults[1]; c.Player1 != "Carol" || c.WinnerChanged || c.CountedInStats {
+		t.Errorf("expected Carol's game score change to be uncounted, got %+v", c)
+	}
+	// Erin gained a label; Dave had an empty one and none now, which is no change
+	wantLabels := []LabelChange{
+		{Player: "bob", From: "Jeskai Control", To: "Azorius Control"},
+		{Player: "erin", From: "", To: "Mono Red"},
+	}
+	if !reflect.DeepEqual(diff.ArchetypeChanges, wantLabels) {
+		t.Errorf("expected %+v, got %+v", wantLabels, diff.ArchetypeChanges)
+	}
+
+	lost := diffScrapes("1", to, from)
+	if len(lost.ArchetypeChanges) != 2 || lost.ArchetypeChanges[1] != (LabelChange{Player: "erin", From: "Mono Red", To: ""}) {
+		t.Errorf("expected Erin to lose the label in reverse, got %+v", lost.ArchetypeChanges)
+	}
+
+	changes := map[string]DecklistChange{}
+	for _, c := range diff.DecklistChanges {
+		changes[c.Player] = c
+	}
+	if len(changes) != 3 || changes["Alice"].Change != "changed" || changes["Alice"].Swaps != 1 ||
+		changes["Bob"].Change != "removed" || changes["Erin"].Change != "added" {
+		t.Errorf("unexpected decklist changes: %+v", diff.DecklistChanges)
+	}
+}
+
+func TestDiffScrapes_NoChanges(t *testing.T) {
+	data := &scrapeData{matches: map[int][]Match{4: {testMatch(t, "Alice", "", "Alice was assigned a bye")}}}
+	diff := diffScrapes("1", data, data)
+	if !diff.empty() {
+		t.Errorf("expected no changes, got %+v", diff)
+	}
+	if !strings.Contains(formatScrapeDiff(diff), "No changes") {
+		t.Errorf("expected the text output to say so, got %q", formatScrapeDiff(diff))
+	}
+}
+
+func TestOpenScrapeVersion_DirectoryMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tournament-1-player-decks.json"), []byte(`{"alice":"Izzet Prowess"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	version, err := openScrapeVersion(dir, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := loadScrapeData(version)
+	if err != nil {
+		t.Fatalf("expected missing files to count as empty, got %v", err)
+	}
+	if len(data.matches) != 0 || data.playerDecks["alice"] != "Izzet Prowess" {
+		t.Errorf("unexpected data: %+v", data)
+	}
+
+	if _, err := openScrapeVersion(filepath.Join(dir, "nope"), "1"); err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// similarDecksPerDeck is how many neighbours the scrape stores in each deck's similar field
+	similarDecksPerDeck = 5
+	// defaultSideboardWeight scales sideboard copies relative to main deck copies
+	defaultSideboardWeight = 0.5
+)
+
+// SimilarDeck is one neighbour of a deck. TournamentID is only set for
+// searches that span several tournaments.
+type SimilarDeck struct {
+	TournamentID string  `json:"tournamentId,omitempty"`
+	PlayerName   string  `json:"playerName"`
+	Archetype    string  `json:"archetype"`
+	Similarity   float64 `json:"similarity"`
+}
+
+// similarityMetrics map a metric name to a similarity in [0, 1] between two deck vectors
+var similarityMetrics = map[string]func(a, b map[string]float64) float64{
+	"jaccard": weightedJaccard,
+	"cosine":  func(a, b map[string]float64) float64 { return 1 - cosineDistance(a, b) },
+}
+
+// weightedJaccard returns the sum of per-card minimum counts over the sum of maximums
+func weightedJaccard(a, b map[string]float64) float64 {
+	var minSum, maxSum float64
+	for key, va := range a {
+		vb := b[key]
+		minSum += math.Min(va, vb)
+		maxSum += math.Max(va, vb)
+	}
+	for key, vb := range b {
+		if _, shared := a[key]; !shared {
+			maxSum += vb
+		}
+	}
+	if maxSum == 0 {
+		return 0
+	}
+	return minSum / maxSum
+}
+
+// weightedDeckVector is deckVector with sideboard copies scaled by sideboardWeight
+func weightedDeckVector(deck DeckInfo, sideboardWeight float64) map[string]float64 {
+	vector := make(map[string]float64)
+	for _, card := range deck.MainDeck {
+		vector["main:"+card.Name] += float64(card.Quantity)
+	}
+	if sideboardWeight > 0 {
+		for _, card := range deck.Sideboard {
+			vector["side:"+card.Name] += float64(card.Quantity) * sideboardWeight
+		}
+	}
+	return vector
+}
+
+// similarityIndex holds deck vectors from one or more tournaments for nearest-neighbour search
+type similarityIndex struct {
+	similarity      func(a, b map[string]float64) float64
+	sideboardWeight float64
+	entries         []similarityEntry
+}
+
+type similarityEntry struct {
+	tournamentID string
+	playerName   string
+	archetype    string
+	vector       map[string]float64
+}
+
+func newSimilarityIndex(metric string, sideboardWeight float64) (*similarityIndex, error) {
+	similarity, ok := similarityMetrics[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q (want jaccard or cosine)", metric)
+	}
+	return &similarityIndex{similarity: similarity, sideboardWeight: sideboardWeight}, nil
+}
+
+// add indexes a tournament's decks. Decks without a main deck are skipped.
+func (idx *similarityIndex) add(tournamentID string, decklists []DeckInfo) {
+	for _, deck := range decklists {
+		if len(deck.MainDeck) == 0 {
+			continue
+		}
+		idx.entries = append(idx.entries, similarityEntry{
+			tournamentID: tournamentID,
+			playerName:   deck.PlayerName,
+			archetype:    deck.Archetype,
+			vector:       weightedDeckVector(deck, idx.sideboardWeight),
+		})
+	}
+}
+
+// find returns the index of a player's deck, matching the player name normalized
+// and the tournament exactly (any tournament when tournamentID is empty)
+func (idx *similarityIndex) find(tournamentID, playerName string) (int, bool) {
+	wanted := normalizePlayerName(playerName)
+	for i, entry := range idx.entries {
+		if (tournamentID == "" || entry.tournamentID == tournamentID) && normalizePlayerName(entry.playerName) == wanted {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// nearest returns the k decks most similar to entry i, excluding itself. Ties are
+// broken by tournament and player name so results are stable.
+func (idx *similarityIndex) nearest(i, k int) []SimilarDeck {
+	target := idx.entries[i]
+	var neighbours []SimilarDeck
+	for j, entry := range idx.entries {
+		if j == i {
+			continue
+		}
+		neighbours = append(neighbours, SimilarDeck{
+			TournamentID: entry.tournamentID,
+			PlayerName:   entry.playerName,
+			Archetype:    entry.archetype,
+			Similarity:   idx.similarity(target.vector, entry.vector),
+		})
+	}
+
+	sort.SliceStable(neighbours, func(a, b int) bool {
+		if neighbours[a].Similarity != neighbours[b].Similarity {
+			return neighbours[a].Similarity > neighbours[b].Similarity
+		}
+		if neighbours[a].TournamentID != neighbours[b].TournamentID {
+			return neighbours[a].TournamentID < neighbours[b].TournamentID
+		}
+		return neighbours[a].PlayerName < neighbours[b].PlayerName
+	})
+
+	if len(neighbours) > k {
+		neighbours = neighbours[:k]
+	}
+	return neighbours
+}
+
+// addSimilarDecks sets Similar on every deck to its k nearest decks in the same
+// tournament, using weighted Jaccard
+func addSimilarDecks(decklists []DeckInfo, k int) {
+	idx, _ := newSimilarityIndex("jaccard", defaultSideboardWeight)
+	idx.add("", decklists)
+
+	// add indexes decks with a main deck in order, so entries line up with them
+	j := 0
+	for i := range decklists {
+		if len(decklists[i].MainDeck) == 0 {
+			continue
+		}
+		decklists[i].Similar = idx.nearest(j, k)
+		j++
+	}
+}
+
+// runSimilar implements the similar command
+func runSimilar(args []string) error {
+	fs := flag.NewFlagSet("similar", flag.ExitOnError)
+	tournamentFlag := fs.String("tournament", "", "Tournament ID of the player's deck (required)")
+	playerFlag := fs.String("player", "", "Player whose deck to find neighbours for (required)")
+	kFlag := fs.Int("k", 10, "Number of similar decks to return")
+	allFlag := fs.Bool("all", false, "Search every registry tournament instead of only -tournament")
+	metricFlag := fs.String("metric", "jaccard", "Similarity metric: jaccard (weighted) or cosine")
+	sideboardWeightFlag := fs.Float64("sideboard-weight", defaultSideboardWeight, "Weight of a sideboard copy relative to a main deck copy (0 ignores sideboards)")
+	jsonFlag := fs.Bool("json", false, "Print JSON instead of a table")
+	fs.Parse(args)
+
+	if *tournamentFlag == "" || *playerFlag == "" {
+		return fmt.Errorf("-tournament and -player are required")
+	}
+	if *kFlag <= 0 {
+		return fmt.Errorf("-k must be positive")
+	}
+
+	idx, err := newSimilarityIndex(*metricFlag, *sideboardWeightFlag)
+	if err != nil {
+		return err
+	}
+
+	tournamentIDs := []string{*tournamentFlag}
+	if *allFlag {
+		registry, err := loadRegistry(filepath.Join(outputDir, registryFile))
+		if err != nil {
+			return err
+		}
+		tournamentIDs = nil
+		for _, t := range registry {
+			tournamentIDs = append(tournamentIDs, t.ID)
+		}
+	}
+	for _, id := range tournamentIDs {
+		decklists, err := loadDecklists(id)
+		switch {
+		case *allFlag && errors.Is(err, os.ErrNotExist):
+			continue
+		case err != nil:
+			return err
+		}
+		idx.add(id, decklists)
+	}
+
+	i, ok := idx.find(*tournamentFlag, *playerFlag)
+	if !ok {
+		return fmt.Errorf("player %q has no deck in tournament %s", *playerFlag, *tournamentFlag)
+	}
+	neighbours := idx.nearest(i, *kFlag)
+
+	if *jsonFlag {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(neighbours)
+	}
+
+	target := idx.entries[i]
+	log.Printf("Decks most similar to %s (%s) among %d decks:", target.playerName, target.archetype, len(idx.entries)-1)
+	for rank, deck := range neighbours {
+		fmt.Printf("%3d  %.3f  %-12s %-28s %s\n", rank+1, deck.Similarity, deck.TournamentID, deck.PlayerName, deck.Archetype)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeightedJaccard(t *testing.T) {
+	a := map[string]float64{"main:Opt": 4, "main:Shock": 2}
+	b := map[string]float64{"main:Opt": 2, "main:Negate": 2}
+
+	// min: Opt 2; max: Opt 4 + Shock 2 + Negate 2
+	if got := weightedJaccard(a, b); math.Abs(got-0.25) > 1e-9 {
+		t.Errorf("expected 0.25, got %v", got)
+	}
+	if got := weightedJaccard(a, a); got != 1 {
+		t.Errorf("identical decks should have similarity 1, got %v", got)
+	}
+	if got := weightedJaccard(nil, nil); got != 0 {
+		t.Errorf("empty decks should have similarity 0, got %v", got)
+	}
+}
+
+func TestWeightedDeckVector(t *testing.T) {
+	deck := DeckInfo{
+		MainDeck:  []CardInfo{{Quantity: 4, Name: "Opt"}},
+		Sideboard: []CardInfo{{Quantity: 2, Name: "Opt"}},
+	}
+	vector := weightedDeckVector(deck, 0.5)
+	if vector["main:Opt"] != 4 || vector["side:Opt"] != 1 {
+		t.Errorf("unexpected vector: %v", vector)
+	}
+	if _, ok := weightedDeckVector(deck, 0)["side:Opt"]; ok {
+		t.Error("zero sideboard weight should drop sideboard cards")
+	}
+}
+
+func TestSimilarityIndex_Nearest(t *testing.T) {
+	idx, err := newSimilarityIndex("jaccard", defaultSideboardWeight)
+	if err != nil {
+		t.Fatalf("newSimilarityIndex returned error: %v", err)
+	}
+	idx.add("A", []DeckInfo{
+		{PlayerName: "Alice", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Shock"}}},
+		{PlayerName: "Bob", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 3, Name: "Shock"}}},
+		{PlayerName: "Carol", Archetype: "Mono-G", MainDeck: []CardInfo{{Quantity: 4, Name: "Llanowar Elves"}}},
+		{PlayerName: "No List", Archetype: "Unknown"},
+	})
+	idx.add("B", []DeckInfo{
+		{PlayerName: "Dave", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Shock"}}},
+	})
+
+	if len(idx.entries) != 4 {
+		t.Fatalf("decks without cards should be skipped, got %d entries", len(idx.entries))
+	}
+
+	i, ok := idx.find("A", "alice")
+	if !ok {
+		t.Fatal("find should match player names case-insensitively")
+	}
+	if _, ok := idx.find("B", "Alice"); ok {
+		t.Error("find should respect the tournament")
+	}
+
+	got := idx.nearest(i, 2)
+	if len(got) != 2 || got[0].PlayerName != "Dave" || got[0].TournamentID != "B" || got[0].Similarity != 1 || got[1].PlayerName != "Bob" {
+		t.Errorf("unexpected neighbours: %+v", got)
+	}
+
+	if _, err := newSimilarityIndex("euclid", 1); err == nil {
+		t.Error("expected error for unknown metric")
+	}
+}
+
+func TestAddSimilarDecks(t *testing.T) {
+	decklists := []DeckInfo{
+		{PlayerName: "Alice", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}}},
+		{PlayerName: "Bob", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 3, Name: "Opt"}}},
+		{PlayerName: "Carol", Archetype: "Mono-G", MainDeck: []CardInfo{{Quantity: 4, Name: "Llanowar Elves"}}},
+		{PlayerName: "No List", Archetype: "Unknown"},
+	}
+	addSimilarDecks(decklists, 1)
+
+	if len(decklists[0].Similar) != 1 || decklists[0].Similar[0].PlayerName != "Bob" || decklists[0].Similar[0].TournamentID != "" {
+		t.Errorf("Alice's nearest deck should be Bob's: %+v", decklists[0].Similar)
+	}
+	if decklists[3].Similar != nil {
+		t.Errorf("decks without cards get no neighbours: %+v", decklists[3].Similar)
+	}
+}
+
+func TestAddSimilarDecks_SharedPlayerName(t *testing.T) {
+	decklists := []DeckInfo{
+		{PlayerName: "Alex Smith", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}}},
+		{PlayerName: "alex smith", Archetype: "Mono-G", MainDeck: []CardInfo{{Quantity: 4, Name: "Llanowar Elves"}}},
+		{PlayerName: "Bob", Archetype: "Izzet", MainDeck: []CardInfo{{Quantity: 3, Name: "Opt"}}},
+		{PlayerName: "Carol", Archetype: "Mono-G", MainDeck: []CardInfo{{Quantity: 3, Name: "Llanowar Elves"}}},
+	}
+	addSimilarDecks(decklists, 1)
+
+	if len(decklists[0].Similar) != 1 || decklists[0].Similar[0].PlayerName != "Bob" {
+		t.Errorf("first Alex Smith's nearest deck should be Bob's: %+v", decklists[0].Similar)
+	}
+	if len(decklists[1].Similar) != 1 || decklists[1].Similar[0].PlayerName != "Carol" {
+		t.Errorf("second alex smith's nearest deck should be Carol's: %+v", decklists[1].Similar)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sp3c1/protour-data-viz/scraper/carddata"
+)
+
+const (
+	legalityFile     = "legality.json"
+	minMainDeckSize  = 60
+	maxSideboardSize = 15
+	maxCopies        = 4
+)
+
+// basicLands may be played in any number
+var basicLands = map[string]bool{
+	"Plains": true, "Island": true, "Swamp": true, "Mountain": true, "Forest": true, "Wastes": true,
+	"Snow-Covered Plains": true, "Snow-Covered Island": true, "Snow-Covered Swamp": true,
+	"Snow-Covered Mountain": true, "Snow-Covered Forest": true, "Snow-Covered Wastes": true,
+}
+
+// copyLimitExceptions are nonbasic cards whose rules text overrides the four-copy limit.
+// A limit of 0 means any number.
+var copyLimitExceptions = map[string]int{
+	"Relentless Rats":         0,
+	"Rat Colony":              0,
+	"Shadowborn Apostle":      0,
+	"Persistent Petitioners":  0,
+	"Dragon's Approach":       0,
+	"Slime Against Humanity":  0,
+	"Hare Apparent":           0,
+	"Templar Knight":          0,
+	"Cid, Timeless Artificer": 0,
+	"Seven Dwarves":           7,
+	"Nazgûl":                  9,
+}
+
+// basicLandKeys and copyLimitKeys are basicLands and copyLimitExceptions keyed by
+// carddata.NormalizeName, so case, accent and face separator variants match
+var (
+	basicLandKeys = func() map[string]bool {
+		keys := make(map[string]bool, len(basicLands))
+		for name := range basicLands {
+			keys[carddata.NormalizeName(name)] = true
+		}
+		return keys
+	}()
+	copyLimitKeys = func() map[string]int {
+		keys := make(map[string]int, len(copyLimitExceptions))
+		for name, limit := range copyLimitExceptions {
+			keys[carddata.NormalizeName(name)] = limit
+		}
+		return keys
+	}()
+)
+
+// FormatLegality is one format's entry in data/legality.json. When Legal is
+// non-empty it is the complete card pool; Banned cards are never allowed and
+// Restricted cards (as in Vintage) are allowed one copy.
+type FormatLegality struct {
+	Legal      []string `json:"legal,omitempty"`
+	Banned     []string `json:"banned"`
+	Restricted []string `json:"restricted,omitempty"`
+}
+
+// LegalityRules maps format names (as in Tournament.Format) to their legality
+type LegalityRules map[string]FormatLegality
+
+// DeckViolation is one problem found in a decklist
+type DeckViolation struct {
+	Rule    string `json:"rule"`
+	Card    string `json:"card,omitempty"`
+	Message string `json:"message"`
+}
+
+// InvalidDeck lists a player's deck violations for the run report
+type InvalidDeck struct {
+	PlayerName string          `json:"playerName"`
+	Violations []DeckViolation `json:"violations"`
+}
+
+// ValidationSummary is the decklist validation section of the run report
+type ValidationSummary struct {
+	Format       string         `json:"format"`
+	Decks        int            `json:"decks"`
+	ValidDecks   int            `json:"validDecks"`
+	RuleCounts   map[string]int `json:"ruleCounts"`
+	InvalidDecks []InvalidDeck  `json:"invalidDecks"`
+}
+
+// loadLegalityRules reads the local legality/banlist file
+func loadLegalityRules(path string) (LegalityRules, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read legality %s: %w", path, err)
+	}
+
+	var rules LegalityRules
+	if err := json.Unmarshal(bytes, &rules); err != nil {
+		return nil, fmt.Errorf("parse legality %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// forFormat returns the entry for format, matched case-insensitively
+func (r LegalityRules) forFormat(format string) (FormatLegality, bool) {
+	for name, legality := range r {
+		if strings.EqualFold(name, format) {
+			return legality, true
+		}
+	}
+	return FormatLegality{}, false
+}
+
+// deckValidator checks decks against construction rules and a format's legality.
+// Card names are compared with carddata.NormalizeName.
+type deckValidator struct {
+	format     string
+	legal      map[string]bool
+	banned     map[string]bool
+	restricted map[string]bool
+	cards      *carddata.DB
+}
+
+func newDeckValidator(format string, legality FormatLegality, cards *carddata.DB) *deckValidator {
+	v := &deckValidator{format: format, banned: make(map[string]bool), restricted: make(map[string]bool), cards: cards}
+	if len(legality.Legal) > 0 {
+		v.legal = make(map[string]bool)
+		for _, name := range legality.Legal {
+			v.legal[carddata.NormalizeName(name)] = true
+		}
+	}
+	for _, name := range legality.Banned {
+		v.banned[carddata.NormalizeName(name)] = true
+	}
+	for _, name := range legality.Restricted {
+		v.restricted[carddata.NormalizeName(name)] = true
+	}
+	return v
+}
+
+// validate returns every violation in deck, in a stable order
+func (v *deckValidator) validate(deck DeckInfo) []DeckViolation {
+	var violations []DeckViolation
+
+	mainCount := 0
+	for _, card := range deck.MainDeck {
+		mainCount += card.Quantity
+	}
+	if mainCount < minMainDeckSize {
+		violations = append(violations, DeckViolation{
+			Rule:    "main-deck-size",
+			Message: fmt.Sprintf("main deck has %d cards (minimum %d)", mainCount, minMainDeckSize),
+		})
+	}
+
+	sideCount := 0
+	for _, card := range deck.Sideboard {
+		sideCount += card.Quantity
+	}
+	if sideCount > maxSideboardSize {
+		violations = append(violations, DeckViolation{
+			Rule:    "sideboard-size",
+			Message: fmt.Sprintf("sideboard has %d cards (maximum %d)", sideCount, maxSideboardSize),
+		})
+	}
+
+	// Count copies by normalized name, reporting each card by its first spelling
+	copies := make(map[string]int)
+	display := make(map[string]string)
+	var keys []string
+	for _, section := range [][]CardInfo{deck.MainDeck, deck.Sideboard} {
+		for _, card := range section {
+			key := carddata.NormalizeName(card.Name)
+			if _, seen := display[key]; !seen {
+				display[key] = card.Name
+				keys = append(keys, key)
+			}
+			copies[key] += card.Quantity
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := display[key]
+		if v.isRestricted(name) {
+			if copies[key] > 1 {
+				violations = append(violations, DeckViolation{
+					Rule:    "restricted",
+					Card:    name,
+					Message: fmt.Sprintf("%d copies of %s, which is restricted in %s (maximum 1)", copies[key], name, v.format),
+				})
+			}
+		} else if limit := copyLimit(name); limit > 0 && copies[key] > limit {
+			violations = append(violations, DeckViolation{
+				Rule:    "copy-limit",
+				Card:    name,
+				Message: fmt.Sprintf("%d copies of %s (maximum %d)", copies[key], name, limit),
+			})
+		}
+		if violation, ok := v.checkLegality(name); !ok {
+			violations = append(violations, violation)
+		}
+	}
+
+	return violations
+}
+
+// copyLimit returns the maximum copies of a card, or 0 for no limit
+func copyLimit(name string) int {
+	key := carddata.NormalizeName(name)
+	if basicLandKeys[key] {
+		return 0
+	}
+	if limit, ok := copyLimitKeys[key]; ok {
+		return limit
+	}
+	return maxCopies
+}
+
+// isRestricted reports whether the legality file or card data restricts a card
+// to one copy in the validator's format
+func (v *deckValidator) isRestricted(name string) bool {
+	if v.restricted[carddata.NormalizeName(name)] {
+		return true
+	}
+	if v.cards != nil {
+		if card, found := v.cards.Resolve(name); found {
+			status, known := card.Legality(v.format)
+			return known && status == "restricted"
+		}
+	}
+	return false
+}
+
+// checkLegality checks the banlist, then the file's card pool, then card data legalities
+func (v *deckValidator) checkLegality(name string) (DeckViolation, bool) {
+	key := carddata.NormalizeName(name)
+
+	if v.banned[key] {
+		return DeckViolation{Rule: "banned", Card: name, Message: fmt.Sprintf("%s is banned in %s", name, v.format)}, false
+	}
+	if v.legal != nil && !v.legal[key] && !basicLandKeys[key] {
+		return DeckViolation{Rule: "not-legal", Card: name, Message: fmt.Sprintf("%s is not in the %s card pool", name, v.format)}, false
+	}
+	if v.cards != nil {
+		if card, found := v.cards.Resolve(name); found {
+			if status, known := card.Legality(v.format); known && status != "legal" && status != "restricted" {
+				return DeckViolation{Rule: "not-legal", Card: name, Message: fmt.Sprintf("%s is %s in %s", name, status, v.format)}, false
+			}
+		}
+	}
+	return DeckViolation{}, true
+}
+
+// validateDecklists sets Violations on every deck and summarises them for the run report
+func validateDecklists(decklists []DeckInfo, format string, legality FormatLegality, cards *carddata.DB) *ValidationSummary {
+	validator := newDeckValidator(format, legality, cards)
+	summary := &ValidationSummary{
+		Format:       format,
+		RuleCounts:   make(map[string]int),
+		InvalidDecks: []InvalidDeck{},
+	}
+
+	for i := range decklists {
+		violations := validator.validate(decklists[i])
+		decklists[i].Violations = violations
+		summary.Decks++
+
+		if len(violations) == 0 {
+			summary.ValidDecks++
+			continue
+		}
+		for _, violation := range violations {
+			summary.RuleCounts[violation.Rule]++
+		}
+		summary.InvalidDecks = append(summary.InvalidDecks, InvalidDeck{PlayerName: decklists[i].PlayerName, Violations: violations})
+	}
+
+	sort.Slice(summary.InvalidDecks, func(i, j int) bool {
+		return summary.InvalidDecks[i].PlayerName < summary.InvalidDecks[j].PlayerName
+	})
+
+	return summary
+}
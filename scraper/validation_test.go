@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sp3c1/protour-data-viz/scraper/carddata"
+)
+
+func TestDeckValidator(t *testing.T) {
+	validator := newDeckValidator("Standard", FormatLegality{Banned: []string{"Up the Beanstalk"}}, nil)
+
+	valid := DeckInfo{
+		MainDeck:  []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 32, Name: "Island"}, {Quantity: 24, Name: "Hare Apparent"}},
+		Sideboard: []CardInfo{{Quantity: 3, Name: "Negate"}, {Quantity: 12, Name: "Snow-Covered Island"}},
+	}
+	if violations := validator.validate(valid); len(violations) != 0 {
+		t.Errorf("expected no violations, got %+v", violations)
+	}
+
+	invalid := DeckInfo{
+		MainDeck:  []CardInfo{{Quantity: 3, Name: "Opt"}, {Quantity: 1, Name: "Up the Beanstalk"}, {Quantity: 40, Name: "Island"}},
+		Sideboard: []CardInfo{{Quantity: 2, Name: "Opt"}, {Quantity: 14, Name: "Negate"}},
+	}
+	var rules []string
+	for _, v := range validator.validate(invalid) {
+		rules = append(rules, v.Rule+":"+v.Card)
+	}
+	want := []string{"main-deck-size:", "sideboard-size:", "copy-limit:Negate", "copy-limit:Opt", "banned:Up the Beanstalk"}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("expected %v, got %v", want, rules)
+	}
+}
+
+func TestDeckValidator_RestrictedAndNameVariants(t *testing.T) {
+	validator := newDeckValidator("Vintage", FormatLegality{Restricted: []string{"Ancestral Recall"}}, nil)
+	deck := DeckInfo{
+		MainDeck: []CardInfo{
+			{Quantity: 2, Name: "Ancestral Recall"}, {Quantity: 3, Name: "Opt"}, {Quantity: 10, Name: "hare apparent"},
+			{Quantity: 45, Name: "island"},
+		},
+		Sideboard: []CardInfo{{Quantity: 2, Name: "opt"}},
+	}
+
+	var rules []string
+	for _, v := range validator.validate(deck) {
+		rules = append(rules, v.Rule+":"+v.Card)
+	}
+	want := []string{"restricted:Ancestral Recall", "copy-limit:Opt"}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("expected %v, got %v", want, rules)
+	}
+
+	deck.MainDeck[0].Quantity, deck.MainDeck[3].Quantity = 1, 46
+	deck.Sideboard = nil
+	if violations := validator.validate(deck); len(violations) != 0 {
+		t.Errorf("one copy of a restricted card is allowed, got %+v", violations)
+	}
+}
+
+func TestDeckValidator_CardPool(t *testing.T) {
+	validator := newDeckValidator("Standard", FormatLegality{Legal: []string{"Opt", "Lim-Dûl's Vault"}}, nil)
+	deck := DeckInfo{MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Lim-Dul's Vault"}, {Quantity: 4, Name: "Brainstorm"}, {Quantity: 48, Name: "Island"}}}
+
+	violations := validator.validate(deck)
+	if len(violations) != 1 || violations[0].Rule != "not-legal" || violations[0].Card != "Brainstorm" {
+		t.Errorf("expected only Brainstorm outside the card pool, got %+v", violations)
+	}
+}
+
+func TestDeckValidator_CardDataLegalities(t *testing.T) {
+	bulk := []map[string]interface{}{
+		{"oracle_id": "o-opt", "name": "Opt", "layout": "normal", "legalities": map[string]string{"standard": "legal", "vintage": "legal"}},
+		{"oracle_id": "o-brainstorm", "name": "Brainstorm", "layout": "normal", "legalities": map[string]string{"standard": "not_legal", "vintage": "restricted"}},
+	}
+	data, _ := json.Marshal(bulk)
+	path := filepath.Join(t.TempDir(), "cards.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	cards, err := carddata.Load(path)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	validator := newDeckValidator("Standard", FormatLegality{}, cards)
+	deck := DeckInfo{MainDeck: []CardInfo{{Quantity: 4, Name: "Opt"}, {Quantity: 4, Name: "Brainstorm"}, {Quantity: 52, Name: "Island"}}}
+
+	violations := validator.validate(deck)
+	if len(violations) != 1 || violations[0].Card != "Brainstorm" {
+		t.Errorf("expected Brainstorm to be flagged from card data, got %+v", violations)
+	}
+
+	vintage := newDeckValidator("Vintage", FormatLegality{}, cards)
+	violations = vintage.validate(deck)
+	if len(violations) != 1 || violations[0].Rule != "restricted" || violations[0].Card != "Brainstorm" {
+		t.Errorf("expected Brainstorm to be restricted in Vintage, got %+v", violations)
+	}
+}
+
+func TestValidateDecklists(t *testing.T) {
+	decklists := []DeckInfo{
+		{PlayerName: "Zed", MainDeck: []CardInfo{{Quantity: 60, Name: "Island"}}},
+		{PlayerName: "Failed Fetch", MainDeck: []CardInfo{}, Sideboard: []CardInfo{}},
+	}
+
+	summary := validateDecklists(decklists, "Standard", FormatLegality{}, nil)
+
+	if summary.Decks != 2 || summary.ValidDecks != 1 || summary.RuleCounts["main-deck-size"] != 1 {
+		t.Errorf("summary wrong: %+v", summary)
+	}
+	if len(summary.InvalidDecks) != 1 || summary.InvalidDecks[0].PlayerName != "Failed Fetch" {
+		t.Errorf("empty main deck should be reported: %+v", summary.InvalidDecks)
+	}
+	if len(decklists[1].Violations) != 1 || decklists[0].Violations != nil {
+		t.Errorf("violations should be stored on the decks: %+v", decklists)
+	}
+}
@@ -3,13 +3,48 @@
 export interface CardInfo {
   quantity: number;
   name: string;
+  // Present when the scraper ran with card data (-carddata)
+  oracleId?: string;
+  manaValue?: number;
+  colors?: string;
+  typeLine?: string;
+  rarity?: string;
+}
+
+export interface DeckSummary {
+  colors: string;
+  colorIdentity: string;
+  manaCurve: number[]; // index = mana value, last bucket is 7+
+  lands: number;
+  creatures: number;
+  spells: number;
+  avgManaValue: number;
+}
+
+export interface SimilarDeck {
+  playerName: string;
+  archetype: string;
+  similarity: number; // weighted Jaccard, 0-1
+}
+
+export interface DeckViolation {
+  rule: string;
+  card?: string;
+  message: string;
 }
 
 export interface DeckInfo {
   playerName: string;
   archetype: string;
+  classifiedArchetype?: string;
+  decklistId?: string;
+  mainHash?: string;
+  sideboardHash?: string;
   mainDeck: CardInfo[];
   sideboard: CardInfo[];
+  summary?: DeckSummary;
+  violations?: DeckViolation[];
+  similar?: SimilarDeck[];
 }
 
 export interface Competitor {
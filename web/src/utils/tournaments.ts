@@ -54,8 +54,44 @@ export function hasData(tournamentId: string): boolean {
   }
 }
 
+/**
+ * Newest schema version of each data file these loaders understand. Must match
+ * currentSchemaVersions in scraper/schema.go for the kinds loaded here.
+ */
+const SUPPORTED_SCHEMA_VERSIONS: Record<string, number> = {
+  matches: 2,
+  decklists: 2,
+  'player-decks': 1,
+  stats: 1,
+};
+
+/**
+ * Fail the build if the scraper wrote a data file newer than the loaders support,
+ * rather than rendering it wrong. Tournaments without a manifest predate schema
+ * versions and are treated as version 1.
+ */
+function checkSchemaVersions(tournamentId: string): void {
+  let manifest: { files?: Record<string, { schemaVersion?: number }> };
+  try {
+    manifest = JSON.parse(
+      readFileSync(resolve(DATA_DIR, `tournament-${tournamentId}-manifest.json`), 'utf-8')
+    );
+  } catch {
+    return;
+  }
+  for (const [kind, supported] of Object.entries(SUPPORTED_SCHEMA_VERSIONS)) {
+    const version = manifest.files?.[kind]?.schemaVersion ?? 1;
+    if (version > supported) {
+      throw new Error(
+        `tournament-${tournamentId}-${kind}.json is schema v${version}, but the web loaders support up to v${supported}`
+      );
+    }
+  }
+}
+
 /** Load a tournament's full dataset for getStaticPaths/page props. */
 export function loadTournamentData(tournament: Tournament): TournamentData {
+  checkSchemaVersions(tournament.id);
   const file = (kind: string) =>
     JSON.parse(
       readFileSync(resolve(DATA_DIR, `tournament-${tournament.id}-${kind}.json`), 'utf-8')